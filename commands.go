@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"lanno/internal/file_stat"
+	"lanno/internal/query"
+
+	"golang.org/x/term"
+)
+
+// commands maps subcommand names to their implementations. Each receives the
+// arguments following the subcommand name.
+var commands = map[string]func(args []string){
+	"ls":    listCommand,
+	"find":  findCommand,
+	"prune": pruneCommand,
+	"mv":    moveCommand,
+	"cp":    copyCommand,
+
+	"reconcile": reconcileCommand,
+}
+
+// parseArgs parses flags appearing anywhere in args and returns the
+// remaining positional arguments.
+func parseArgs(flags *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		flags.Parse(args)
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// dirArg returns the single optional directory argument of a subcommand.
+func dirArg(flags *flag.FlagSet, positional []string) string {
+	switch len(positional) {
+	case 0:
+		return "."
+	case 1:
+		return positional[0]
+	default:
+		flags.Usage()
+		os.Exit(exitSyntax)
+		return ""
+	}
+}
+
+func listCommand(args []string) {
+	flags := flag.NewFlagSet("ls", flag.ExitOnError)
+	var opts file_stat.ListOptions
+	flags.BoolVar(&opts.Recursive, "recursive", false, "include subdirectories")
+	flags.StringVar(&opts.Tag, "tag", "", "only list files with this tag")
+	columns := flags.String("columns", "", "optional table columns, comma-separated: "+strings.Join(file_stat.Columns, ", ")+" (default from config.json)")
+	flags.BoolVar(&opts.NoHeader, "no-header", false, "leave out the column titles")
+	flags.StringVar(&opts.Format, "format", file_stat.FormatTable, "output format: "+strings.Join(file_stat.Formats, ", "))
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: lanno ls [--recursive] [--tag <tag>] [--columns <list>] [--no-header] [--format <format>] [dir]")
+		flags.PrintDefaults()
+	}
+	dir := dirArg(flags, parseArgs(flags, args))
+	columnsSet := false
+	flags.Visit(func(f *flag.Flag) {
+		columnsSet = columnsSet || f.Name == "columns"
+	})
+	if columnsSet {
+		var err error
+		if opts.Columns, err = file_stat.ParseColumns(*columns); err != nil {
+			fail(err)
+		}
+	} else {
+		// The configuration is only read when --columns does not override it
+		opts.Columns = configColumns()
+	}
+
+	// Fit the terminal; in pipes print every cell in full
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+		opts.Width = width
+	}
+	if err := file_stat.WriteListing(os.Stdout, dir, opts); err != nil {
+		fail(err)
+	}
+}
+
+func findCommand(args []string) {
+	flags := flag.NewFlagSet("find", flag.ExitOnError)
+	var opts file_stat.ListOptions
+	dir := flags.String("dir", ".", "directory to search below")
+	flags.StringVar(&opts.Tag, "tag", "", "only files with this tag")
+	desc := flags.String("desc", "", "only files whose description matches this regular expression")
+	name := flags.String("name", "", "only files whose name matches this glob, e.g. '*.go'")
+	flags.BoolVar(&opts.Walk.FollowSymlinks, "follow-symlinks", false, "descend into symlinked directories")
+	flags.BoolVar(&opts.Walk.FollowSymlinks, "L", false, "shorthand for --follow-symlinks")
+	flags.BoolVar(&opts.Walk.OneFileSystem, "one-file-system", false, "do not descend into other file systems")
+	flags.BoolVar(&opts.Walk.OneFileSystem, "x", false, "shorthand for --one-file-system")
+	flags.BoolVar(&opts.NoHeader, "no-header", false, "leave out the column titles")
+	flags.StringVar(&opts.Format, "format", file_stat.FormatPath, "output format: "+strings.Join(file_stat.Formats, ", "))
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: lanno find [--dir <dir>] [--tag <tag>] [--desc <regexp>] [--name <glob>] [-L] [-x] [--format <format>] [query]")
+		fmt.Fprintln(os.Stderr, `Example: lanno find 'tag:backend AND NOT tag:deprecated'`)
+		flags.PrintDefaults()
+	}
+	queryText := strings.Join(parseArgs(flags, args), " ")
+
+	expr, err := query.Parse(queryText)
+	if err != nil {
+		fail(fmt.Errorf("%w: %v", file_stat.ErrSyntax, err))
+	}
+	descPattern, err := regexp.Compile(*desc)
+	if err != nil {
+		fail(fmt.Errorf("%w: --desc: %v", file_stat.ErrSyntax, err))
+	}
+	if _, err := filepath.Match(*name, ""); err != nil {
+		fail(fmt.Errorf("%w: --name: %v", file_stat.ErrSyntax, err))
+	}
+
+	opts.Recursive = true
+	opts.Match = func(entry file_stat.Entry) bool {
+		if *name != "" {
+			if ok, _ := filepath.Match(*name, entry.Info.Name); !ok {
+				return false
+			}
+		}
+		return descPattern.MatchString(entry.Info.Description) && expr.Match(entry.QueryItem())
+	}
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+		opts.Width = width
+	}
+	if err := file_stat.WriteListing(os.Stdout, *dir, opts); err != nil {
+		fail(err)
+	}
+}
+
+func pruneCommand(args []string) {
+	flags := flag.NewFlagSet("prune", flag.ExitOnError)
+	yes := flags.Bool("yes", false, "remove the orphaned annotations")
+	dryRun := flags.Bool("dry-run", false, "print a JSON report and change nothing")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: lanno prune [--yes] [--dry-run] [dir]")
+		flags.PrintDefaults()
+	}
+	dir := dirArg(flags, parseArgs(flags, args))
+
+	report, err := file_stat.Prune(dir, *yes && !*dryRun)
+	if err != nil {
+		fail(err)
+	}
+
+	if *dryRun {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	for _, info := range report.Orphans {
+		fmt.Printf("%s\t%s\t%s\n", info.Name, strings.Join(info.Tags, ", "), info.Description)
+	}
+	switch {
+	case len(report.Orphans) == 0:
+		fmt.Println("No orphaned annotations.")
+	case report.Removed:
+		fmt.Printf("Removed %d orphaned annotation(s).\n", len(report.Orphans))
+	default:
+		fmt.Printf("Run with --yes to remove %d orphaned annotation(s).\n", len(report.Orphans))
+	}
+}
+
+func moveCommand(args []string) {
+	transferCommand("mv", "Usage: lanno mv <source> <target>", file_stat.MoveFile, args)
+}
+
+func copyCommand(args []string) {
+	transferCommand("cp", "Usage: lanno cp <source> <target>", file_stat.CopyFile, args)
+}
+
+// transferCommand runs mv or cp, which both take exactly a source and a target.
+func transferCommand(name, usage string, transfer func(src, dst string) error, args []string) {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, usage)
+	}
+	positional := parseArgs(flags, args)
+	if len(positional) != 2 {
+		flags.Usage()
+		os.Exit(exitSyntax)
+	}
+	if err := transfer(positional[0], positional[1]); err != nil {
+		fail(err)
+	}
+}
+
+func reconcileCommand(args []string) {
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	yes := flags.Bool("yes", false, "re-key the matched annotations")
+	recursive := flags.Bool("recursive", false, "also match across subdirectories")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: lanno reconcile [--yes] [--recursive] [dir]")
+		flags.PrintDefaults()
+	}
+	dir := dirArg(flags, parseArgs(flags, args))
+
+	renames, err := file_stat.FindRenames(dir, *recursive)
+	if err != nil {
+		fail(err)
+	}
+	for _, r := range renames {
+		fmt.Printf("%s -> %s\n", r.From, r.To)
+	}
+	switch {
+	case len(renames) == 0:
+		fmt.Println("No renamed files found.")
+	case *yes:
+		if err := file_stat.ApplyRenames(renames); err != nil {
+			fail(err)
+		}
+		fmt.Printf("Re-keyed %d annotation(s).\n", len(renames))
+	default:
+		fmt.Printf("Run with --yes to re-key %d annotation(s).\n", len(renames))
+	}
+}
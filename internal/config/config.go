@@ -0,0 +1,47 @@
+// Package config reads the user's lanno settings from config.json in the
+// lanno directory of the user configuration directory, for example
+// ~/.config/lanno/config.json on Linux.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Config holds the user's settings.
+type Config struct {
+	Columns []string `json:"columns"` // Optional columns shown unless --columns is given
+}
+
+// Path returns where the configuration is read from.
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "lanno", "config.json"), nil
+}
+
+// Load reads the configuration. Without a configuration file, or without a
+// configuration directory, it returns the zero Config.
+func Load() (Config, error) {
+	var cfg Config
+	path, err := Path()
+	if err != nil {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	} else if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %v", path, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package file_stat
+
+import "os"
+
+// deviceOf is not supported on this platform, so every file system is
+// treated as the same one.
+func deviceOf(info os.FileInfo) (uint64, bool) {
+	return 0, false
+}
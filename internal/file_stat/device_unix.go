@@ -0,0 +1,17 @@
+//go:build unix
+
+package file_stat
+
+import (
+	"os"
+	"syscall"
+)
+
+// deviceOf returns the device the file described by info is stored on.
+func deviceOf(info os.FileInfo) (uint64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(stat.Dev), true
+}
@@ -1,18 +1,16 @@
 package file_stat
 
 import (
-	"bytes"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"io/fs"
 	"os"
-	"os/exec"
-	"runtime"
+	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
-	"golang.org/x/term"
-
+	"lanno/internal/store"
 	"lanno/internal/table"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -22,28 +20,40 @@ import (
 var baseStyle = lipgloss.NewStyle().
 	BorderStyle(lipgloss.NormalBorder())
 
-var kLoginWidth = [6]int{5, 1, 4, 6, 0, 0}
-
-const kFlexIndex = 1 // tag column is flexible
 const (
 	columnKeyFilename    = "filename"
 	columnKeyIcons       = "icons"
 	columnKeyTags        = "tags"
 	columnKeyDescription = "description"
-	// columnKeyCreatedTime = "created_time"
-	// columnKeyUpdatedTime = "updated_time"
-	// columnKeyVisitedTime = "visited_time"
+	columnKeyEntry       = "entry" // Hidden: the complete Entry the row shows
+	columnKeySize        = "size"    // Human-readable size of a file
+	columnKeyMode        = "mode"    // Permissions, as ls -l shows them
+	columnKeyOwner       = "owner"   // User owning the file
+	columnKeyGroup       = "group"   // Group owning the file
+	columnKeyTarget      = "target"  // Where a symlink points
+	columnKeyEntries     = "entries" // Number of entries of a directory
+	columnKeyCreatedTime = "created_time"
+	columnKeyUpdatedTime = "updated_time"
+	columnKeyVisitedTime = "visited_time"
 )
 
 type FileModel struct {
-	table       *table.Table
-	searchMode  bool
-	searchQuery string
-	allRows     []table.Row
-	inputMode   bool
-	inputPrompt string
-	inputBuffer string
-	inputTarget string
+	table        *table.Table
+	dir          string          // Directory being listed
+	treeMode     bool            // Whether directories expand in place
+	expanded     map[string]bool // Paths of directories expanded in tree mode
+	timeMode     timeColumns     // Timestamp columns shown, switched with T
+	columns      []string        // Optional columns shown, see Columns
+	searchMode   bool
+	searchQuery  string
+	searchErr    error      // Why searchQuery does not parse, if it doesn't
+	searchKind   searchKind // How searchQuery is matched, switched with tab
+	allRows      []table.Row
+	inputMode    bool
+	inputPrompt  string
+	inputBuffer  string
+	inputTargets []string // Paths the command being entered applies to
+	err          error    // Last error from loading or saving annotations
 }
 
 func (m FileModel) Init() tea.Cmd {
@@ -51,259 +61,280 @@ func (m FileModel) Init() tea.Cmd {
 }
 
 func (m FileModel) View() string {
-	view := baseStyle.Render(m.table.View())
+	view := breadcrumb(m.dir)
+	if sorting := m.table.SortDescription(); sorting != "" {
+		view += "  (sorted by " + sorting + ")"
+	}
+	view += "\n" + baseStyle.Render(m.table.View())
 	if m.searchMode {
-		view += "\nSearch: " + m.searchQuery
+		view += "\nSearch (" + m.searchKind.String() + ", tab to switch): " + m.searchQuery
+		if m.searchErr != nil {
+			view += "  (" + m.searchErr.Error() + ")"
+		}
+	} else if m.searchQuery != "" {
+		view += "\nFilter (" + m.searchKind.String() + "): " + m.searchQuery + "  (esc to clear)"
 	}
 	if m.inputMode {
 		view += "\n" + m.inputPrompt + m.inputBuffer
 	}
+	if m.err != nil {
+		view += "\nError: " + m.err.Error()
+	}
 	return view + "\n"
 }
 
-type FileInfo struct {
-	Name        string   `json:"name"`
-	Tags        []string `json:"tags"`
-	Description string   `json:"description"`
-}
-
-type LannoFileData struct {
-	FileInfo []FileInfo `json:"file_info"`
-}
-
+// CommandItem holds the timestamps of a file. Times the platform or file
+// system does not record are zero.
 type CommandItem struct {
 	path            string
-	lastUpdatedTime string
-	lastVisitedTime string
-	createTime      string
+	lastUpdatedTime time.Time // Modification time
+	lastVisitedTime time.Time // Access time
+	createTime      time.Time // Birth time
 }
 
 type Item struct {
 	path            string
 	description     string
 	tag             []string
-	lastUpdatedTime string
-	lastVisitedTime string
-	createTime      string
+	lastUpdatedTime time.Time
+	lastVisitedTime time.Time
+	createTime      time.Time
 }
 
-func dateFormatDarwin(date string) string {
-	parsedTime, err := time.Parse("Jan 2 15:04:05 2006", date)
-	if err != nil {
-		return ""
-	}
-	formattedTime := parsedTime.Format("06/01/02 15:04:05")
-	return formattedTime
+// annoFileName is the per-directory file that holds annotations for the
+// entries of that directory.
+const annoFileName = ".lanno.json"
+
+// OpenStore returns the annotation store for the entries of dir. It can be
+// replaced to plug in a different backend.
+// Setting LANNO_BACKUP keeps the previous version of each .lanno.json as
+// .lanno.json.bak.
+var OpenStore = func(dir string) store.Store {
+	return store.NewJSONStore(filepath.Join(dir, annoFileName)).
+		WithBackup(os.Getenv("LANNO_BACKUP") != "")
 }
 
-func TagCommand(command []string, path string) {
-	filePath := "./.lanno.json"
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		_, err := os.Create(filePath)
-		if err != nil {
-			return
-		}
-	}
+// ErrSyntax is returned for tag commands that cannot be parsed.
+var ErrSyntax = errors.New("bad command syntax")
 
-	file, err := os.Open(filePath)
-	if err != nil {
-		return
+// EditAnnotation applies a tag command to the annotation of name in s.
+//
+// An empty command clears the description. A command starting with +<tag> or
+// -<tag> adds or removes tags and may be followed by a new description;
+// anything else sets the description.
+func EditAnnotation(s store.Store, name string, command []string) error {
+	info, found := s.Get(name)
+	if !found {
+		info = store.FileInfo{Name: name, Tags: []string{}}
 	}
-	defer file.Close()
 
-	byteValue, err := io.ReadAll(file)
-	if err != nil {
-		return
+	if len(command) == 0 || !isTagCommand(command[0]) {
+		info.Description = strings.TrimSpace(strings.Join(command, " "))
+		return s.Put(info)
 	}
 
-	// Check if the JSON file is empty or has invalid format
-	if len(byteValue) == 0 || !json.Valid(byteValue) {
-		// Initialize with empty structure if file is empty or invalid
-		byteValue = []byte("{}")
+	tagCount := 0
+	for tagCount < len(command) && isTagCommand(command[tagCount]) {
+		tagCount++
+	}
+	if description := command[tagCount:]; len(description) > 0 {
+		// Words like "-1" after the first description word are description text
+		info.Description = strings.TrimSpace(strings.Join(description, " "))
 	}
 
-	path = path[strings.LastIndex(path, "/")+1:]
-	var data LannoFileData
-	err = json.Unmarshal(byteValue, &data)
-	if err != nil {
-		return
-	}
-
-	found := false
-	fileIndex := -1
-	var tagList []string
-	for i, item := range data.FileInfo {
-		if item.Name == path {
-			tagList = item.Tags
-			found = true
-			fileIndex = i
-			break
+	tagList := info.Tags
+	for _, tagCommand := range command[:tagCount] {
+		if len(tagCommand) == 1 {
+			return fmt.Errorf("%w: missing tag name after %q", ErrSyntax, tagCommand)
+		}
+		tagString := "#" + tagCommand[1:]
+		if tagCommand[0] == '+' {
+			if indexOf(tagList, tagString) < 0 {
+				tagList = append(tagList, tagString)
+			}
+		} else if tagCommand[0] == '-' {
+			if i := indexOf(tagList, tagString); i >= 0 {
+				tagList = append(tagList[:i], tagList[i+1:]...)
+			}
 		}
 	}
+	info.Tags = tagList
+	return s.Put(info)
+}
 
-	if !found {
-		fileIndex = len(data.FileInfo)
-		data.FileInfo = append(data.FileInfo, FileInfo{Name: path, Tags: []string{}, Description: ""})
-	}
+// isTagCommand reports whether arg is a +<tag> or -<tag> command.
+func isTagCommand(arg string) bool {
+	return arg != "" && (arg[0] == '+' || arg[0] == '-')
+}
 
-	if len(command) == 0 {
-		description := ""
-		for _, commandItem := range command {
-			description += commandItem + " "
+func indexOf(list []string, value string) int {
+	for i, item := range list {
+		if item == value {
+			return i
 		}
-		description = strings.TrimSpace(description)
-		data.FileInfo[fileIndex].Description = description
-		file, err = os.OpenFile(filePath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
+	}
+	return -1
+}
+
+// UpdateStore loads the store of dir, applies fn and saves the result. Stores
+// implementing store.Locker stay locked for the whole cycle so concurrent
+// lanno processes do not lose each other's updates.
+func UpdateStore(dir string, fn func(s store.Store) error) error {
+	return updateStores([]string{dir}, func(stores []store.Store) error {
+		return fn(stores[0])
+	})
+}
+
+// updateStores is UpdateStore for several directories at once. Directories
+// that resolve to the same path share one store. Locks are taken in path
+// order so that two updates on the same directories cannot deadlock, and
+// stores are saved in the order the directories are given.
+func updateStores(dirs []string, fn func(stores []store.Store) error) error {
+	stores := make([]store.Store, len(dirs))
+	keys := make([]string, len(dirs))
+	opened := make(map[string]store.Store)
+	for i, dir := range dirs {
+		key, err := filepath.Abs(dir)
 		if err != nil {
-			return
+			return err
 		}
-		byteValue, err = json.MarshalIndent(data, "", "  ")
-		if err != nil {
-			return
+		if _, ok := opened[key]; !ok {
+			opened[key] = OpenStore(dir)
 		}
-		_, err = file.Write(byteValue)
-		if err != nil {
-			return
-		}
-		defer file.Close()
-		return
+		stores[i] = opened[key]
+		keys[i] = key
 	}
-	
-	firstCommand := command[0]
-	if firstCommand[0] != '+' && firstCommand[0] != '-' {
-		description := ""
-		for _, commandItem := range command {
-			description += commandItem + " "
-		}
-		description = strings.TrimSpace(description)
-		
-		// If description is empty or just spaces, set it to empty string
-		if description == "" {
-			description = ""
-		}
-		data.FileInfo[fileIndex].Description = description
-		file, err = os.OpenFile(filePath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
-		if err != nil {
-			return
+
+	lockOrder := make([]string, 0, len(opened))
+	for key := range opened {
+		lockOrder = append(lockOrder, key)
+	}
+	sort.Strings(lockOrder)
+	for _, key := range lockOrder {
+		if locker, ok := opened[key].(store.Locker); ok {
+			unlock, err := locker.Lock()
+			if err != nil {
+				return err
+			}
+			defer unlock()
 		}
-		byteValue, err = json.MarshalIndent(data, "", "  ")
-		if err != nil {
-			return
+		if err := opened[key].Load(); err != nil {
+			return err
 		}
-		_, err = file.Write(byteValue)
-		if err != nil {
-			return
+	}
+
+	snapshots := make(map[string][]store.FileInfo)
+	if len(opened) > 1 {
+		for key, s := range opened {
+			snapshots[key] = s.List()
 		}
-		defer file.Close()
-		return
 	}
-	for _, tagCommand := range command {
-		tagString := "#" + tagCommand[1:]
-		if tagCommand[0] == '+' {
-			tagList = append(tagList, tagString)
-		} else if tagCommand[0] == '-' {
-			for i, tag := range tagList {
-				if tag == tagString {
-					tagList = append(tagList[:i], tagList[i+1:]...)
-					break
+	if err := fn(stores); err != nil {
+		return err
+	}
+
+	var saved []string
+	for i, s := range stores {
+		if indexOf(saved, keys[i]) >= 0 {
+			continue
+		}
+		if err := s.Save(); err != nil {
+			// Put back the stores already saved, so that an annotation
+			// moved between them is not left in both
+			for _, key := range saved {
+				if restoreErr := restoreStore(opened[key], snapshots[key]); restoreErr != nil {
+					return fmt.Errorf("%w; restoring %s also failed: %v", err, key, restoreErr)
 				}
 			}
+			return err
 		}
+		saved = append(saved, keys[i])
 	}
-	data.FileInfo[fileIndex].Tags = tagList
-	file, err = os.OpenFile(filePath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
-	if err != nil {
-		return
-	}
-	byteValue, err = json.MarshalIndent(data, "", "  ")
-	if err != nil {
-		return
-	}
-	_, err = file.Write(byteValue)
-	if err != nil {
-		return
-	}
-
-	defer file.Close()
-	return
+	return nil
 }
 
-func GetInfoFromAnnoFile(path string) map[string]FileInfo {
-	filePath := path + "/.lanno.json"
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		file, err := os.Create(filePath)
-		file.WriteString("{}")
-		file.Close()
-		if err != nil {
-			return map[string]FileInfo{}
+// restoreStore saves s with the annotations it had when snapshot was taken.
+func restoreStore(s store.Store, snapshot []store.FileInfo) error {
+	for _, info := range s.List() {
+		if err := s.Delete(info.Name); err != nil {
+			return err
 		}
 	}
-	file, err := os.Open(filePath)
-	if err != nil {
-		return map[string]FileInfo{}
+	for _, info := range snapshot {
+		if err := s.Put(info); err != nil {
+			return err
+		}
 	}
-	defer file.Close()
+	return s.Save()
+}
 
-	byteValue, err := io.ReadAll(file)
-	if err != nil {
-		return map[string]FileInfo{}
-	}
-	var data LannoFileData
-	err = json.Unmarshal(byteValue, &data)
-	if err != nil {
-		return map[string]FileInfo{}
-	}
-	fileInfoMap := make(map[string]FileInfo)
-	for _, item := range data.FileInfo {
-		if strings.HasPrefix(item.Name, "./") {
-			item.Name = item.Name[2:]
+// TagCommand applies a tag command to the file at path and saves the result
+// in the .lanno.json of the file's directory. Unless force is set, the file
+// must exist.
+func TagCommand(command []string, path string, force bool) error {
+	return TagFiles(command, []string{path}, force)
+}
+
+// TagFiles applies the same tag command to every file in paths. Each
+// directory's .lanno.json is written once, and nothing is written unless the
+// command applies to all files.
+func TagFiles(command []string, paths []string, force bool) error {
+	cleaned := make([]string, len(paths))
+	dirs := make([]string, len(paths))
+	for i, path := range paths {
+		path = filepath.Clean(path)
+		if !force {
+			if _, err := os.Lstat(path); os.IsNotExist(err) {
+				return fmt.Errorf("%s: %w (use --force to annotate it anyway)", path, fs.ErrNotExist)
+			} else if err != nil {
+				return err
+			}
 		}
-		fileInfoMap[item.Name] = item
-	}
-	return fileInfoMap
+		cleaned[i] = path
+		dirs[i] = filepath.Dir(path)
+	}
+	return updateStores(dirs, func(stores []store.Store) error {
+		for i, path := range cleaned {
+			name := filepath.Base(path)
+			if err := EditAnnotation(stores[i], name, command); err != nil {
+				return err
+			}
+			// Record the content fingerprint so renames can be detected later
+			info, _ := stores[i].Get(name)
+			if Fingerprint(&info, path) != nil {
+				continue
+			}
+			if err := stores[i].Put(info); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-func GoExecStatCommand(command string) string {
-	cmd := exec.Command("/bin/sh", "-c", command)
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	err := cmd.Run()
-	if err != nil {
-		return ""
+func GetInfoFromAnnoFile(path string) (map[string]store.FileInfo, error) {
+	s := OpenStore(path)
+	if err := s.Load(); err != nil {
+		return map[string]store.FileInfo{}, err
 	}
-	result := out.String()
-	if runtime.GOOS == "linux" {
-		// TODO: format time on linux
-	} else if runtime.GOOS == "darwin" {
-		result = strings.TrimSpace(result)
-		result = dateFormatDarwin(result)
+	fileInfoMap := make(map[string]store.FileInfo)
+	for _, item := range s.List() {
+		fileInfoMap[item.Name] = item
 	}
-	return result
+	return fileInfoMap, nil
 }
 
+// GetInfoFromFileSystem reads the timestamps of the file at path, without
+// following a symlink. If the file cannot be read, all times are zero.
 func GetInfoFromFileSystem(path string) CommandItem {
-	createdTimeCommand := ""
-	lastUpdatedTimeCommand := ""
-	lastVisitedTimeCommand := ""
-
-	if runtime.GOOS == "linux" {
-		createdTimeCommand = "stat -c %w " + path
-		lastUpdatedTimeCommand = "stat -c %y " + path
-		lastVisitedTimeCommand = "stat -c %x " + path
-	} else if runtime.GOOS == "darwin" {
-		createdTimeCommand = "stat -f %SB " + path
-		lastUpdatedTimeCommand = "stat -f %Sm " + path
-		lastVisitedTimeCommand = "stat -f %Sa " + path
-	} else {
-		// Running on a different operating system
-	}
-
-	return CommandItem{
-		path:            path,
-		lastUpdatedTime: GoExecStatCommand(lastUpdatedTimeCommand),
-		lastVisitedTime: GoExecStatCommand(lastVisitedTimeCommand),
-		createTime:      GoExecStatCommand(createdTimeCommand),
+	item := CommandItem{path: path}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return item
 	}
+	item.lastUpdatedTime = info.ModTime()
+	item.lastVisitedTime, item.createTime = statTimes(path, info)
+	return item
 }
 
 // Add these as package-level variables
@@ -318,70 +349,164 @@ func SetTerminalDimensions(width, height int) {
 	termHeight = height
 }
 
-func GetTableItems(path string) []table.Row {
-	lannoInfoMap := GetInfoFromAnnoFile(path)
-	files, err := os.ReadDir(path)
-	if err != nil {
-		return []table.Row{}
+// GetTableItems lists the entries of path. Files are still listed when the
+// annotations cannot be loaded; the load error is returned alongside them.
+func GetTableItems(path string) ([]table.Row, error) {
+	entries, err := ListDir(path)
+	if entries == nil && err != nil {
+		return []table.Row{}, err
 	}
 	
-	// Use termWidth instead of getting it directly
-	availableWidth := termWidth - 6
-	nameWidth := (availableWidth * 30) / 100
-	tagsWidth := (availableWidth * 20) / 100
-	descWidth := availableWidth - nameWidth - tagsWidth
-	
 	var resultTable []table.Row
-	for _, file := range files {
-		if strings.HasPrefix(file.Name(), ".") {
+	for _, entry := range entries {
+		// Cells hold the full text; the table truncates it when rendering
+		resultTable = append(resultTable, table.NewRow(table.RowData{
+			columnKeyFilename:    entry.Icon() + " " + entry.Info.Name,
+			columnKeyTags:        strings.Join(entry.Info.Tags, ", "),
+			columnKeyDescription: entry.Info.Description,
+			columnKeyEntry:       entry,
+		}).WithID(entry.Path))
+	}
+	return resultTable, err
+}
+
+// sortOptions are the orders the s key cycles through in the browser.
+var sortOptions = []table.SortOption{
+	{Key: columnKeyFilename, Title: "Name"},
+	{Key: columnKeyTags, Title: "Tags"},
+	{Key: columnKeyDescription, Title: "Description"},
+	{Key: columnKeySize, Title: "Size"},
+	{Key: columnKeyUpdatedTime, Title: "Modified"},
+	{Key: columnKeyCreatedTime, Title: "Created"},
+	{Key: columnKeyVisitedTime, Title: "Accessed"},
+}
+
+// withSorting sets up t to sort rows of entries by sortOptions, with
+// directories first as an option.
+func withSorting(t *table.Table) *table.Table {
+	return t.WithSortOptions(sortOptions).
+		WithSortValue(sortValue).
+		WithGroup("directories first", func(row table.Row) bool {
+			entry, _ := rowEntry(row)
+			return entry.IsDir
+		})
+}
+
+// sortValue returns what a row is sorted by for key: the full name, tags or
+// description of its entry, or the size or a timestamp of the file. Missing
+// files have no size or times.
+func sortValue(row table.Row, key string) interface{} {
+	entry, ok := rowEntry(row)
+	if !ok {
+		return row.Data[key]
+	}
+	switch key {
+	case columnKeyFilename:
+		return entry.Info.Name
+	case columnKeyTags:
+		return strings.Join(entry.Info.Tags, ", ")
+	case columnKeyDescription:
+		return entry.Info.Description
+	case columnKeySize:
+		if entry.DirEntry == nil {
+			return nil
+		}
+		info, err := entry.DirEntry.Info()
+		if err != nil {
+			return nil
+		}
+		return info.Size()
+	case columnKeyUpdatedTime:
+		return entryModTime(entry)
+	case columnKeyCreatedTime, columnKeyVisitedTime:
+		if entry.Missing {
+			return time.Time{}
+		}
+		created, _, accessed := fileTimes(entry.Path)
+		if key == columnKeyCreatedTime {
+			return created
+		}
+		return accessed
+	}
+	return row.Data[key]
+}
+
+// rowEntry returns the Entry a row was made from.
+func rowEntry(row table.Row) (Entry, bool) {
+	entry, ok := row.Data[columnKeyEntry].(Entry)
+	return entry, ok
+}
+
+// GetTreeItems lists the entries of path like GetTableItems, followed after
+// each directory in expanded by its own entries, nested one level deeper.
+// The first error encountered is returned alongside the rows.
+func GetTreeItems(path string, expanded map[string]bool) ([]table.Row, error) {
+	return appendTreeItems(nil, path, "", 0, expanded)
+}
+
+func appendTreeItems(rows []table.Row, path string, parent string, depth int, expanded map[string]bool) ([]table.Row, error) {
+	items, firstErr := GetTableItems(path)
+	for _, row := range items {
+		rows = append(rows, row.WithParent(parent, depth))
+		if entry, _ := rowEntry(row); !entry.IsDir || !expanded[row.ID] {
 			continue
 		}
-		lannoinfoItem := lannoInfoMap[file.Name()]
-		icon := "📄"
-		if file.IsDir() {
-			icon = "📁"
+
+		// Mark the directory as open and add its children below it
+		filename, _ := row.Data[columnKeyFilename].(string)
+		rows[len(rows)-1].Data[columnKeyFilename] = openDirIcon + strings.TrimPrefix(filename, dirIcon)
+		var err error
+		rows, err = appendTreeItems(rows, row.ID, row.ID, depth+1, expanded)
+		if firstErr == nil {
+			firstErr = err
 		}
-		
-		// Use calculated widths for truncation
-		filename := truncateText(icon+" "+file.Name(), nameWidth)
-		tags := truncateText(strings.Join(lannoinfoItem.Tags, ", "), tagsWidth)
-		desc := truncateText(lannoinfoItem.Description, descWidth)
-
-		row := table.NewRow(table.RowData{
-			columnKeyFilename:    filename,
-			columnKeyTags:        tags,
-			columnKeyDescription: desc,
-		})
-		resultTable = append(resultTable, row)
 	}
-	return resultTable
+	return rows, firstErr
 }
 
-func NewModel() FileModel {
-	// Use termWidth instead of getting it directly
-	availableWidth := termWidth - 6
-	nameWidth := (availableWidth * 30) / 100
-	tagsWidth := (availableWidth * 20) / 100
-	descWidth := availableWidth - nameWidth - tagsWidth
-	
-	columns := []table.Column{
-		table.NewColumn(columnKeyFilename, "Name", nameWidth).WithFiltered(true),
-		table.NewColumn(columnKeyTags, "Tags", tagsWidth).WithFiltered(true),
-		table.NewColumn(columnKeyDescription, "Description", descWidth),
+// Icons shown in front of file names
+const (
+	fileIcon    = "📄"
+	dirIcon     = "📁"
+	openDirIcon = "📂" // Directory expanded in tree mode
+	missingIcon = "❌" // Annotation whose file no longer exists
+)
+
+// OrphanedEntries returns the annotations in infos that have no matching
+// entry in files, sorted by name.
+func OrphanedEntries(infos map[string]store.FileInfo, files []os.DirEntry) []store.FileInfo {
+	present := make(map[string]bool, len(files))
+	for _, file := range files {
+		present[file.Name()] = true
+	}
+	var orphans []store.FileInfo
+	for name, info := range infos {
+		if !present[name] {
+			orphans = append(orphans, info)
+		}
 	}
+	sort.Slice(orphans, func(i, j int) bool {
+		return orphans[i].Name < orphans[j].Name
+	})
+	return orphans
+}
+
+func NewModel() FileModel {
+	columns := tableColumns(nil, timesHidden)
 	
-	rows := GetTableItems(".")
+	rows, err := GetTableItems(".")
 	
 	// Calculate page size based on terminal height
-	// Account for: header(1) + separator(1) + page indicator(1) + prompt(1) + buffer(1) = 5 lines
-	pageSize := termHeight - 5
+	// Account for: breadcrumb(1) + header(1) + separator(1) + page indicator(1) + prompt(1) + buffer(1) = 6 lines
+	pageSize := termHeight - 6
 	if pageSize < 1 {
 		pageSize = 1
 	}
 	
-	t := table.New(columns).
+	t := withSorting(table.New(columns)).
 		WithFiltered(true).
 		WithFocused(true).
+		WithWidth(tableWidth()).
 		WithPageSize(pageSize). // Use dynamic page size
 		WithRows(rows)
 	
@@ -389,91 +514,74 @@ func NewModel() FileModel {
 	t.SetStyles(s)
 	
 	return FileModel{
-		table:   t,
-		allRows: rows,
+		table:    t,
+		dir:      ".",
+		expanded: map[string]bool{},
+		allRows:  rows,
+		err:      err,
 	}
 }
 
-// Helper function to truncate text with ellipsis
-func truncateText(text string, width int) string {
-	if width <= 3 {
-		return text
-	}
-	runes := []rune(text)
-	if len(runes) <= width {
-		return text
+// tableColumns returns the Name/Tags/Description columns, followed by the
+// optional columns keys and the timestamp columns shown for mode. The table
+// lays them out when rendering: the name and tags fit their content, and the
+// description takes the rest of the width.
+func tableColumns(keys []string, mode timeColumns) []table.Column {
+	columns := []table.Column{
+		table.NewColumn(columnKeyFilename, "Name", 0).WithFiltered(true).WithFit(true).WithMinWidth(10),
+		table.NewColumn(columnKeyTags, "Tags", 0).WithFiltered(true).WithFit(true).WithMinWidth(6).WithMaxWidth(30),
+		table.NewColumn(columnKeyDescription, "Description", 0).WithFit(true).WithMinWidth(10).WithFlex(1),
 	}
-	return string(runes[:width-3]) + "..."
+	columns = append(columns, metadataColumns(keys)...)
+	return withTimeColumns(columns, mode)
+}
+
+// tableWidth returns the width of the browser's table inside its border.
+func tableWidth() int {
+	return termWidth - 2
 }
 
 // Add this type near the top of the file with other types
-type refreshMsg struct{}
+type refreshMsg struct {
+	err error // Error to report after refreshing, if any
+}
 
 // Add this new type for screen clearing
 type clearScreenMsg struct{}
 
 func RefreshTableModel(m FileModel) FileModel {
-	// Clear any existing state to prevent duplication
+	// Keep the marks, sort order and column layout, then clear any existing state to prevent duplication
+	var marked []string
+	var sorting table.Sort
+	var layout table.Layout
+	if m.table != nil {
+		marked = m.table.MarkedIDs()
+		sorting = m.table.SortState()
+		layout = m.table.LayoutState()
+	}
 	m.table = nil
 	
 	// Get fresh data
-	rows := GetTableItems(".")
-	
-	// Get current table properties
-	width, _, err := term.GetSize(0)
-	if err != nil {
-		width = 80
-	}
-	
-	// Calculate column widths
-	availableWidth := width - 6
-	nameWidth := (availableWidth * 30) / 100
-	tagsWidth := (availableWidth * 20) / 100
-	descWidth := availableWidth - nameWidth - tagsWidth
+	rows, loadErr := m.tableItems()
 	
-	// Create new columns with same properties
-	columns := []table.Column{
-		table.NewColumn(columnKeyFilename, "Name", nameWidth).WithFiltered(true),
-		table.NewColumn(columnKeyTags, "Tags", tagsWidth).WithFiltered(true),
-		table.NewColumn(columnKeyDescription, "Description", descWidth),
-	}
-	// Calculate dynamic name column width based on max filename length
-	maxNameWidth := 0
-	for _, row := range rows {
-		if filename, ok := row.Data[columnKeyFilename].(string); ok {
-			nameLen := len(filename) + 1 // Add 1 for padding
-			if nameLen > maxNameWidth {
-				maxNameWidth = nameLen
-			}
-		}
-	}
-	
-	// Ensure name column width is reasonable (not too small or too large)
-	if maxNameWidth < 10 {
-		maxNameWidth = 10 // Minimum width
-	} else if maxNameWidth > nameWidth {
-		maxNameWidth = nameWidth // Cap at original allocation
-	}
-	
-	// Redistribute the space - give any saved space to description
-	descWidth = availableWidth - maxNameWidth - tagsWidth
-	
-	// Update column widths
-	columns[0].Width = maxNameWidth // Name column
-	columns[2].Width = descWidth    // Description column
+	columns := tableColumns(m.columns, m.timeMode)
 
 	// Calculate dynamic page size based on current terminal height
-	pageSize := termHeight - 5 // Same calculation as in NewModel
+	pageSize := termHeight - 6 // Same calculation as in NewModel
 	if pageSize < 1 {
 		pageSize = 1
 	}
 
 	// Create a completely new table
-	t := table.New(columns).
+	t := withSorting(table.New(columns)).
+		WithSort(sorting).
+		WithLayout(layout).
 		WithFiltered(true).
 		WithFocused(true).
+		WithWidth(tableWidth()).
 		WithPageSize(pageSize). // Use dynamic page size
-		WithRows(rows)
+		WithRows(rows).
+		WithMarked(marked)
 	
 	// Apply styles
 	s := table.DefaultStyles()
@@ -482,6 +590,10 @@ func RefreshTableModel(m FileModel) FileModel {
 	// Update model with new table and rows
 	m.table = t
 	m.allRows = rows
+	m.err = loadErr
+	if m.searchQuery != "" {
+		m = m.applySearch()
+	}
 	
 	return m
 }
@@ -495,20 +607,26 @@ func (m FileModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	// Handle refresh message
-	if _, ok := msg.(refreshMsg); ok {
-		// Store the current selection index before refreshing
+	if refresh, ok := msg.(refreshMsg); ok {
+		// Store the current selection before refreshing
 		var selectedIndex int
-		if len(m.table.SelectedRows()) > 0 {
+		var selectedID string
+		if row, ok := m.table.CursorRow(); ok {
 			selectedIndex = m.table.Selected
+			selectedID = row.ID
 		}
 		
 		// Refresh the model
 		refreshedModel := RefreshTableModel(m)
 		
-		// Restore the selection if possible
-		if selectedIndex >= 0 && selectedIndex < len(refreshedModel.table.Rows) {
+		// Restore the selection, following the entry if it moved
+		found := selectedID != "" && refreshedModel.table.SelectID(selectedID)
+		if !found && selectedIndex >= 0 && selectedIndex < len(refreshedModel.table.Rows) {
 			refreshedModel.table.Selected = selectedIndex
 		}
+		if refresh.err != nil {
+			refreshedModel.err = refresh.err
+		}
 		
 		return refreshedModel, nil
 	}
@@ -538,11 +656,16 @@ func (m FileModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "enter":
 				// Process the input
 				command := strings.TrimSpace(m.inputBuffer)
+				var err error
 				if command != "" {
 					words := strings.Fields(command)
-					TagCommand(words, m.inputTarget)
+					err = TagFiles(words, m.inputTargets, true)
 				} else {
-					TagCommand([]string{}, m.inputTarget)
+					err = TagFiles([]string{}, m.inputTargets, true)
+				}
+				if err == nil && len(m.inputTargets) > 1 {
+					// The bulk edit is done, so start the next one afresh
+					m.table.ClearMarks()
 				}
 
 				// Store the current selection index before exiting input mode
@@ -550,18 +673,18 @@ func (m FileModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				
 				m.inputMode = false
 				m.inputBuffer = ""
-				m.inputTarget = ""
+				m.inputTargets = nil
 				
 				// Return a command to refresh the model after processing
 				return m, func() tea.Msg { 
 					// Store the selection index in the model before refreshing
 					m.table.Selected = selectedIndex
-					return refreshMsg{} 
+					return refreshMsg{err: err}
 				}
 			case "esc":
 				m.inputMode = false
 				m.inputBuffer = ""
-				m.inputTarget = ""
+				m.inputTargets = nil
 				// Also refresh when canceling input mode
 				return m, func() tea.Msg { return refreshMsg{} }
 			case "backspace", "ctrl+h":
@@ -584,26 +707,34 @@ func (m FileModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "esc":
 				m.searchMode = false
 				m.searchQuery = ""
+				m.searchErr = nil
 				// Reset rows to show all entries
 				m.table = m.table.WithRows(m.allRows)
 				return m, nil
+			case "tab":
+				m.searchKind = m.searchKind.next()
+				return m.applySearch(), nil
 			case "backspace", "ctrl+h":
 				if len(m.searchQuery) > 0 {
 					m.searchQuery = m.searchQuery[:len(m.searchQuery)-1]
 				}
-				m.table = m.table.WithRows(filterRows(m.allRows, m.searchQuery))
-				return m, nil
+				return m.applySearch(), nil
 			default:
 				// Append single-character keys to the query.
 				if len(keyMsg.String()) == 1 {
 					m.searchQuery += keyMsg.String()
-					m.table = m.table.WithRows(filterRows(m.allRows, m.searchQuery))
+					m = m.applySearch()
 				}
 				return m, nil
 			}
 		} else if keyMsg.String() == "/" {
+			// Start a search, or go back to editing the current one
 			m.searchMode = true
+			return m, nil
+		} else if keyMsg.String() == "esc" && m.searchQuery != "" {
 			m.searchQuery = ""
+			m.searchErr = nil
+			m.table = m.table.WithRows(m.allRows)
 			return m, nil
 		}
 
@@ -614,26 +745,55 @@ func (m FileModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Manual refresh
 			return m, func() tea.Msg { return refreshMsg{} }
 		case "ctrl+e":
-			// Get the selected file
-			if len(m.table.SelectedRows()) > 0 {
-				selectedRow := m.table.SelectedRows()[0]
-				if filename, ok := selectedRow.Data[columnKeyFilename].(string); ok {
-					// Extract just the filename without icon
-					parts := strings.SplitN(filename, " ", 2)
-					if len(parts) > 1 {
-						filename = parts[1]
-					}
-					// Remove ellipsis if present
-					filename = strings.TrimSuffix(filename, "...")
-					
-					// Enter input mode
-					m.inputMode = true
-					m.inputPrompt = "Enter command for " + filename + ": "
-					m.inputBuffer = ""
-					m.inputTarget = filename
-					return m, nil
+			// Get the selected files: the marked rows, or the one under the cursor
+			var targets []string
+			for _, row := range m.table.SelectedRows() {
+				if row.ID != "" {
+					targets = append(targets, row.ID)
 				}
 			}
+			if len(targets) > 0 {
+				// Enter input mode
+				m.inputMode = true
+				if len(targets) == 1 {
+					m.inputPrompt = "Enter command for " + filepath.Base(targets[0]) + ": "
+				} else {
+					m.inputPrompt = fmt.Sprintf("Enter command for %d files: ", len(targets))
+				}
+				m.inputBuffer = ""
+				m.inputTargets = targets
+				return m, nil
+			}
+		case "T":
+			// Show the modification time, all timestamps, or none
+			m.timeMode = m.timeMode.next()
+			return m.refreshKeepingSelection(), nil
+		case "t":
+			// Toggle between the flat listing and the tree
+			m.treeMode = !m.treeMode
+			return m.refreshKeepingSelection(), nil
+		case "enter", "l":
+			selectedRow, ok := m.table.CursorRow()
+			if !ok {
+				return m, nil
+			}
+			if entry, _ := rowEntry(selectedRow); !entry.IsDir {
+				return m, nil
+			}
+			if m.treeMode {
+				// Expand or collapse the directory in place
+				m.expanded[selectedRow.ID] = !m.expanded[selectedRow.ID] || (keyMsg.String() == "l")
+				return m.refreshKeepingSelection(), nil
+			}
+			// Descend into the selected directory
+			return m.changeDir(selectedRow.ID, ""), nil
+		case "backspace", "h":
+			if m.treeMode && keyMsg.String() == "h" && len(m.table.Rows) > 0 {
+				return m.collapseSelected(), nil
+			}
+			// Go up, keeping the directory we came from selected
+			parent := filepath.Join(m.dir, "..")
+			return m.changeDir(parent, filepath.Join(parent, filepath.Base(absPath(m.dir)))), nil
 		}
 	}
 
@@ -646,27 +806,90 @@ func (m FileModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
-func filterRows(rows []table.Row, query string) []table.Row {
-	var filtered []table.Row
-	lowerQuery := strings.ToLower(query)
-	for _, row := range rows {
-		name := ""
-		tags := ""
-		desc := ""
-		if val, ok := row.Data[columnKeyFilename]; ok {
-			name = fmt.Sprintf("%v", val)
-		}
-		if val, ok := row.Data[columnKeyTags]; ok {
-			tags = fmt.Sprintf("%v", val)
-		}
-		if val, ok := row.Data[columnKeyDescription]; ok {
-			desc = fmt.Sprintf("%v", val)
-		}
-		if strings.Contains(strings.ToLower(name), lowerQuery) || 
-		   strings.Contains(strings.ToLower(tags), lowerQuery) || 
-		   strings.Contains(strings.ToLower(desc), lowerQuery) {
-			filtered = append(filtered, row)
+// tableItems returns the rows for the current directory in the current mode.
+func (m FileModel) tableItems() ([]table.Row, error) {
+	var rows []table.Row
+	var err error
+	if m.treeMode {
+		rows, err = GetTreeItems(m.dir, m.expanded)
+	} else {
+		rows, err = GetTableItems(m.dir)
+	}
+	addMetadata(rows, m.columns)
+	addTimes(rows, m.timeMode)
+	return rows, err
+}
+
+// WithColumns shows the optional columns keys, see Columns, after the
+// Name/Tags/Description columns.
+func (m FileModel) WithColumns(keys []string) FileModel {
+	m.columns = keys
+	return RefreshTableModel(m)
+}
+
+// refreshKeepingSelection reloads the rows and keeps the cursor on the same
+// index, which stays on the same entry when rows are expanded below it.
+func (m FileModel) refreshKeepingSelection() FileModel {
+	selectedIndex := m.table.Selected
+	m = RefreshTableModel(m)
+	if selectedIndex >= len(m.table.Rows) {
+		selectedIndex = len(m.table.Rows) - 1
+	}
+	if selectedIndex >= 0 {
+		m.table.Selected = selectedIndex
+		m.table.SetPage(selectedIndex / m.table.PageSize)
+	}
+	return m
+}
+
+// collapseSelected collapses the selected directory in tree mode. On any
+// other row it collapses the directory the row is nested in and selects it.
+func (m FileModel) collapseSelected() FileModel {
+	selectedRow, _ := m.table.CursorRow()
+	path := selectedRow.ID
+	if !m.expanded[path] {
+		path = selectedRow.Parent
+	}
+	if path == "" {
+		return m
+	}
+	delete(m.expanded, path)
+	m = RefreshTableModel(m)
+	m.table.SelectID(path)
+	return m
+}
+
+// changeDir lists dir instead of the current directory and selects the row
+// with ID selectID, if given. Search state and marks do not carry over.
+func (m FileModel) changeDir(dir string, selectID string) FileModel {
+	m.dir = filepath.Clean(dir)
+	m.searchMode = false
+	m.searchQuery = ""
+	m.table.ClearMarks() // Marks apply to the directory they were made in
+	m = RefreshTableModel(m)
+	if selectID != "" {
+		m.table.SelectID(filepath.Clean(selectID))
+	}
+	return m
+}
+
+// breadcrumb renders the absolute path of dir, with the home directory
+// shortened to ~.
+func breadcrumb(dir string) string {
+	path := absPath(dir)
+	if home, err := os.UserHomeDir(); err == nil && home != "" {
+		if path == home {
+			path = "~"
+		} else if strings.HasPrefix(path, home+string(filepath.Separator)) {
+			path = "~" + path[len(home):]
 		}
 	}
-	return filtered
+	return "📂 " + path
+}
+
+func absPath(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return dir
 }
@@ -0,0 +1,154 @@
+package file_stat
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Output formats accepted by --format
+const (
+	FormatTable  = "table"
+	FormatPath   = "path" // One path per line
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+	FormatTSV    = "tsv"
+)
+
+// Formats lists the output formats in the order they are documented.
+var Formats = []string{FormatTable, FormatPath, FormatJSON, FormatNDJSON, FormatCSV, FormatTSV}
+
+// Record is the machine-readable form of an Entry.
+type Record struct {
+	Path        string   `json:"path"`
+	Tags        []string `json:"tags"`
+	Description string   `json:"description"`
+	IsDir       bool     `json:"is_dir"`
+	Missing     bool     `json:"missing"`
+	Created     string   `json:"created"`
+	Modified    string   `json:"modified"`
+	Accessed    string   `json:"accessed"`
+}
+
+// recordFields are the column names of the csv and tsv formats.
+var recordFields = []string{"path", "tags", "description", "is_dir", "missing", "created", "modified", "accessed"}
+
+// NewRecord converts an entry, reading its timestamps from the file system.
+func NewRecord(entry Entry) Record {
+	record := Record{
+		Path:        entry.Path,
+		Tags:        entry.Info.Tags,
+		Description: entry.Info.Description,
+		IsDir:       entry.IsDir,
+		Missing:     entry.Missing,
+	}
+	if record.Tags == nil {
+		record.Tags = []string{}
+	}
+	if !entry.Missing {
+		stat := GetInfoFromFileSystem(entry.Path)
+		record.Created = recordTime(stat.createTime)
+		record.Modified = recordTime(stat.lastUpdatedTime)
+		record.Accessed = recordTime(stat.lastVisitedTime)
+	}
+	return record
+}
+
+// recordTime formats t in RFC 3339, or "" when it is unknown.
+func recordTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// fields returns the record as strings in recordFields order.
+func (r Record) fields() []string {
+	return []string{
+		r.Path,
+		strings.Join(r.Tags, " "),
+		r.Description,
+		strconv.FormatBool(r.IsDir),
+		strconv.FormatBool(r.Missing),
+		r.Created,
+		r.Modified,
+		r.Accessed,
+	}
+}
+
+// CheckFormat returns an ErrSyntax error for unknown output formats.
+func CheckFormat(format string) error {
+	if indexOf(Formats, format) < 0 {
+		return fmt.Errorf("%w: unknown format %q (want one of %s)", ErrSyntax, format, strings.Join(Formats, ", "))
+	}
+	return nil
+}
+
+// WriteRecords prints entries in one of the machine-readable formats. The
+// header row of csv and tsv is left out when noHeader is set.
+func WriteRecords(w io.Writer, entries []Entry, format string, noHeader bool) error {
+	records := make([]Record, 0, len(entries))
+	if format != FormatPath {
+		for _, entry := range entries {
+			records = append(records, NewRecord(entry))
+		}
+	}
+
+	switch format {
+	case FormatPath:
+		for _, entry := range entries {
+			if _, err := io.WriteString(w, entry.Path+"\n"); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	case FormatNDJSON:
+		encoder := json.NewEncoder(w)
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if !noHeader {
+			writer.Write(recordFields)
+		}
+		for _, record := range records {
+			writer.Write(record.fields())
+		}
+		writer.Flush()
+		return writer.Error()
+	case FormatTSV:
+		// Tabs and line breaks inside values would break the columns
+		escape := strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+		lines := [][]string{}
+		if !noHeader {
+			lines = append(lines, append([]string(nil), recordFields...)) // Escaped in place below
+		}
+		for _, record := range records {
+			lines = append(lines, record.fields())
+		}
+		for _, line := range lines {
+			for i := range line {
+				line[i] = escape.Replace(line[i])
+			}
+			if _, err := io.WriteString(w, strings.Join(line, "\t")+"\n"); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return CheckFormat(format)
+	}
+}
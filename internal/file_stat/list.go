@@ -0,0 +1,242 @@
+package file_stat
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"lanno/internal/query"
+	"lanno/internal/store"
+	"lanno/internal/table"
+)
+
+// Entry is a listed file together with its annotation.
+type Entry struct {
+	Path     string         // Path of the file, joined onto the listed directory
+	Info     store.FileInfo // Annotation of the file; Name is the base name
+	DirEntry os.DirEntry    // Directory entry of the file, nil if Missing
+	IsDir    bool           // Whether the file is a directory
+	Missing  bool           // Annotated, but the file no longer exists
+}
+
+// Icon returns the icon shown in front of the entry's name.
+func (e Entry) Icon() string {
+	switch {
+	case e.Missing:
+		return missingIcon
+	case e.IsDir:
+		return dirIcon
+	default:
+		return fileIcon
+	}
+}
+
+// HasTag reports whether the entry is tagged with tag, given with or without
+// the leading '#'.
+func (e Entry) HasTag(tag string) bool {
+	return indexOf(e.Info.Tags, "#"+strings.TrimPrefix(tag, "#")) >= 0
+}
+
+// QueryItem returns what queries are matched against for the entry.
+func (e Entry) QueryItem() query.Item {
+	return query.Item{Name: e.Info.Name, Tags: e.Info.Tags, Description: e.Info.Description}
+}
+
+// ListDir returns the non-hidden entries of dir in directory order, followed
+// by the annotations whose files are missing. When the annotations cannot be
+// loaded the files are still listed and the load error is returned as well.
+func ListDir(dir string) ([]Entry, error) {
+	lannoInfoMap, annoErr := GetInfoFromAnnoFile(dir)
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, file := range files {
+		if strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		info := lannoInfoMap[file.Name()]
+		info.Name = file.Name()
+		entries = append(entries, Entry{
+			Path:     filepath.Join(dir, file.Name()),
+			Info:     info,
+			DirEntry: file,
+			IsDir:    file.IsDir(),
+		})
+	}
+	for _, info := range OrphanedEntries(lannoInfoMap, files) {
+		entries = append(entries, Entry{
+			Path:    filepath.Join(dir, info.Name),
+			Info:    info,
+			Missing: true,
+		})
+	}
+	return entries, annoErr
+}
+
+// WalkOptions controls which directories WalkEntries descends into.
+type WalkOptions struct {
+	FollowSymlinks bool // Descend into symlinked directories, skipping loops
+	OneFileSystem  bool // Do not descend into other file systems
+}
+
+// WalkEntries calls fn for the entries of root and, depth first, for those of
+// every non-hidden subdirectory. Directories that cannot be read are skipped;
+// the first error met is returned after the walk.
+func WalkEntries(root string, opts WalkOptions, fn func(entry Entry)) error {
+	w := walker{opts: opts, fn: fn}
+	var ancestors []os.FileInfo
+	if opts.FollowSymlinks || opts.OneFileSystem {
+		info, err := os.Stat(root)
+		if err != nil {
+			return err
+		}
+		w.device, _ = deviceOf(info)
+		ancestors = append(ancestors, info)
+	}
+	return w.walk(root, ancestors)
+}
+
+// walker holds the state of one WalkEntries call.
+type walker struct {
+	opts   WalkOptions
+	fn     func(entry Entry)
+	device uint64 // Device of the root, for OneFileSystem
+}
+
+// walk lists dir and descends into its subdirectories. ancestors holds the
+// directories above and including dir, to detect symlink loops; it is only
+// filled when the options need a stat of each directory.
+func (w *walker) walk(dir string, ancestors []os.FileInfo) error {
+	entries, firstErr := ListDir(dir)
+	for _, entry := range entries {
+		if entry.Missing || !entry.IsDir && !w.opts.FollowSymlinks {
+			w.fn(entry)
+			continue
+		}
+
+		var info os.FileInfo
+		if w.opts.FollowSymlinks || w.opts.OneFileSystem {
+			var err error
+			if info, err = os.Stat(entry.Path); err != nil || !info.IsDir() {
+				// A regular file or a dangling symlink
+				w.fn(entry)
+				continue
+			}
+			entry.IsDir = true
+		}
+		w.fn(entry)
+
+		if info != nil {
+			if device, ok := deviceOf(info); ok && w.opts.OneFileSystem && device != w.device {
+				continue
+			}
+			if isAncestor(info, ancestors) {
+				continue
+			}
+		}
+		if err := w.walk(entry.Path, append(ancestors, info)); firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// isAncestor reports whether info is the same directory as one of ancestors.
+func isAncestor(info os.FileInfo, ancestors []os.FileInfo) bool {
+	for _, ancestor := range ancestors {
+		if ancestor != nil && os.SameFile(info, ancestor) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListOptions controls the output of WriteListing.
+type ListOptions struct {
+	Recursive bool             // Include subdirectories
+	Walk      WalkOptions      // How subdirectories are walked when Recursive is set
+	Columns   []string         // Optional columns of the table, see Columns
+	Tag       string           // Only list entries with this tag, if set
+	Match     func(Entry) bool // Only list entries accepted by Match, if set
+	NoHeader  bool             // Leave out the column titles
+	Format    string           // One of Formats; "" is FormatTable
+	Width     int              // Total width to fit the table into, 0 to fit the content
+}
+
+// CollectEntries lists dir, or the whole tree below it when opts.Recursive is
+// set, keeping the entries that pass opts.Tag and opts.Match.
+func CollectEntries(dir string, opts ListOptions) ([]Entry, error) {
+	var entries []Entry
+	add := func(entry Entry) {
+		if (opts.Tag == "" || entry.HasTag(opts.Tag)) && (opts.Match == nil || opts.Match(entry)) {
+			entries = append(entries, entry)
+		}
+	}
+
+	if opts.Recursive {
+		err := WalkEntries(dir, opts.Walk, add)
+		return entries, err
+	}
+	listed, err := ListDir(dir)
+	for _, entry := range listed {
+		add(entry)
+	}
+	return entries, err
+}
+
+// WriteListing prints the entries of dir in opts.Format. The default is the
+// Name/Tags/Description table as plain text, with names relative to dir.
+func WriteListing(w io.Writer, dir string, opts ListOptions) error {
+	if opts.Format != "" {
+		if err := CheckFormat(opts.Format); err != nil {
+			return err
+		}
+	}
+	entries, err := CollectEntries(dir, opts)
+	if opts.Format != "" && opts.Format != FormatTable {
+		if writeErr := WriteRecords(w, entries, opts.Format, opts.NoHeader); writeErr != nil {
+			return writeErr
+		}
+		return err
+	}
+
+	rows := make([]table.Row, 0, len(entries))
+	for _, entry := range entries {
+		name, relErr := filepath.Rel(dir, entry.Path)
+		if relErr != nil {
+			name = entry.Path
+		}
+		rows = append(rows, table.NewRow(table.RowData{
+			columnKeyFilename:    entry.Icon() + " " + name,
+			columnKeyTags:        strings.Join(entry.Info.Tags, ", "),
+			columnKeyDescription: entry.Info.Description,
+			columnKeyEntry:       entry,
+		}))
+	}
+	addMetadata(rows, opts.Columns)
+
+	columns := tableColumns(opts.Columns, timesHidden)
+	pageSize := len(rows)
+	if pageSize < 1 {
+		pageSize = 1
+	}
+	t := table.New(columns).
+		WithWidth(opts.Width).
+		WithPageSize(pageSize).
+		WithHeader(!opts.NoHeader).
+		WithRows(rows)
+	t.SetStyles(table.PlainStyles())
+
+	for _, line := range strings.Split(t.View(), "\n") {
+		if line = strings.TrimRight(line, " "); line != "" {
+			if _, writeErr := io.WriteString(w, line+"\n"); writeErr != nil {
+				return writeErr
+			}
+		}
+	}
+	return err
+}
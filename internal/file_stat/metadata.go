@@ -0,0 +1,248 @@
+package file_stat
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+
+	"lanno/internal/table"
+)
+
+// Columns lists the optional columns accepted by --columns, in the order they
+// are shown.
+var Columns = []string{columnKeySize, columnKeyMode, columnKeyOwner, columnKeyGroup, columnKeyTarget, columnKeyEntries}
+
+// maxMetadataWidth caps the symlink target column when the table has to fit
+// a width.
+const maxMetadataWidth = 30
+
+// metadataTitles are the headers of the optional columns.
+var metadataTitles = map[string]string{
+	columnKeySize:    "Size",
+	columnKeyMode:    "Mode",
+	columnKeyOwner:   "Owner",
+	columnKeyGroup:   "Group",
+	columnKeyTarget:  "Target",
+	columnKeyEntries: "Entries",
+}
+
+// ParseColumns splits a comma-separated list of optional columns, as given to
+// --columns, and returns an ErrSyntax error for unknown names.
+func ParseColumns(list string) ([]string, error) {
+	return CheckColumns(strings.FieldsFunc(list, func(r rune) bool {
+		return r == ',' || r == ' '
+	}))
+}
+
+// CheckColumns returns an ErrSyntax error if one of names is not an optional
+// column. Otherwise it returns names without repeats, in the given order.
+func CheckColumns(names []string) ([]string, error) {
+	var keys []string
+	for _, name := range names {
+		if indexOf(Columns, name) < 0 {
+			return nil, fmt.Errorf("%w: unknown column %q (want one of %s)", ErrSyntax, name, strings.Join(Columns, ", "))
+		}
+		if indexOf(keys, name) < 0 {
+			keys = append(keys, name)
+		}
+	}
+	return keys, nil
+}
+
+// addMetadata fills in the cells of the optional columns keys.
+func addMetadata(rows []table.Row, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	for _, row := range rows {
+		entry, ok := rowEntry(row)
+		if !ok || entry.DirEntry == nil {
+			continue
+		}
+		info, err := entry.DirEntry.Info()
+		if err != nil {
+			continue
+		}
+		for _, key := range keys {
+			row.Data[key] = metadataCell(entry, info, key)
+		}
+	}
+}
+
+// metadataCell returns the text of the optional column key for entry, whose
+// file info, not following symlinks, is info.
+func metadataCell(entry Entry, info os.FileInfo, key string) string {
+	switch key {
+	case columnKeySize:
+		if entry.IsDir {
+			return ""
+		}
+		return humanSize(info.Size())
+	case columnKeyMode:
+		return formatMode(info.Mode())
+	case columnKeyOwner, columnKeyGroup:
+		uid, gid, ok := fileOwner(info)
+		if !ok {
+			return ""
+		}
+		if key == columnKeyOwner {
+			return userNames.lookup(uid, func(id string) (string, error) {
+				u, err := user.LookupId(id)
+				if err != nil {
+					return "", err
+				}
+				return u.Username, nil
+			})
+		}
+		return groupNames.lookup(gid, func(id string) (string, error) {
+			g, err := user.LookupGroupId(id)
+			if err != nil {
+				return "", err
+			}
+			return g.Name, nil
+		})
+	case columnKeyTarget:
+		if info.Mode()&os.ModeSymlink == 0 {
+			return ""
+		}
+		target, err := os.Readlink(entry.Path)
+		if err != nil {
+			return ""
+		}
+		return target
+	case columnKeyEntries:
+		if !entry.IsDir {
+			return ""
+		}
+		count, err := countEntries(entry.Path)
+		if err != nil {
+			return ""
+		}
+		return strconv.Itoa(count)
+	}
+	return ""
+}
+
+// humanSize formats a size in bytes the way ls -h does, like 512, 4.0K or 12M.
+func humanSize(size int64) string {
+	if size < 1024 {
+		return strconv.FormatInt(size, 10)
+	}
+	value := float64(size)
+	unit := -1
+	for value >= 1024 && unit < len("KMGTPE")-1 {
+		value /= 1024
+		unit++
+	}
+	if value < 10 {
+		return fmt.Sprintf("%.1f%c", value, "KMGTPE"[unit])
+	}
+	return fmt.Sprintf("%.0f%c", value, "KMGTPE"[unit])
+}
+
+// formatMode formats mode the way ls -l does, like drwxr-xr-x or lrwxrwxrwx.
+func formatMode(mode os.FileMode) string {
+	b := []byte("----------")
+	switch {
+	case mode.IsDir():
+		b[0] = 'd'
+	case mode&os.ModeSymlink != 0:
+		b[0] = 'l'
+	case mode&os.ModeNamedPipe != 0:
+		b[0] = 'p'
+	case mode&os.ModeSocket != 0:
+		b[0] = 's'
+	case mode&os.ModeCharDevice != 0:
+		b[0] = 'c'
+	case mode&os.ModeDevice != 0:
+		b[0] = 'b'
+	}
+	const rwx = "rwxrwxrwx"
+	for i := 0; i < 9; i++ {
+		if mode&(1<<uint(8-i)) != 0 {
+			b[i+1] = rwx[i]
+		}
+	}
+	special := func(i int, set bool, letter byte) {
+		if !set {
+			return
+		}
+		if b[i] == 'x' {
+			b[i] = letter
+		} else {
+			b[i] = letter - 'a' + 'A'
+		}
+	}
+	special(3, mode&os.ModeSetuid != 0, 's')
+	special(6, mode&os.ModeSetgid != 0, 's')
+	special(9, mode&os.ModeSticky != 0, 't')
+	return string(b)
+}
+
+// countEntries returns the number of non-hidden entries of the directory
+// dir, the ones lanno lists.
+func countEntries(dir string) (int, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	names, err := f.Readdirnames(-1)
+	count := 0
+	for _, name := range names {
+		if !strings.HasPrefix(name, ".") {
+			count++
+		}
+	}
+	return count, err
+}
+
+// nameCache maps user or group ids to names, which are looked up once.
+type nameCache struct {
+	mu    sync.Mutex
+	names map[string]string
+}
+
+var userNames, groupNames nameCache
+
+// lookup returns the name of id, or id itself if it has no name.
+func (c *nameCache) lookup(id string, find func(id string) (string, error)) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if name, ok := c.names[id]; ok {
+		return name
+	}
+	name, err := find(id)
+	if err != nil {
+		name = id
+	}
+	if c.names == nil {
+		c.names = map[string]string{}
+	}
+	c.names[id] = name
+	return name
+}
+
+// metadataColumns returns the optional columns keys. Those with text of a
+// fixed width keep it; the others fit their content.
+func metadataColumns(keys []string) []table.Column {
+	columns := make([]table.Column, 0, len(keys))
+	for _, key := range keys {
+		column := table.NewColumn(key, metadataTitles[key], 0).WithFit(true).WithMinWidth(5)
+		switch key {
+		case columnKeySize:
+			column = table.NewColumn(key, metadataTitles[key], 6).WithMinWidth(6) // Room for "1023K" and the sort indicator
+		case columnKeyMode:
+			column = table.NewColumn(key, metadataTitles[key], 10).WithMinWidth(10)
+		case columnKeyEntries:
+			column = table.NewColumn(key, metadataTitles[key], 7).WithMinWidth(7)
+		case columnKeyTarget:
+			column = column.WithMinWidth(6).WithMaxWidth(maxMetadataWidth)
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
@@ -0,0 +1,172 @@
+package file_stat
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"lanno/internal/store"
+)
+
+// MoveFile renames src to dst and moves the annotation of src along with it,
+// also when src and dst live in directories with different .lanno.json files.
+// Like mv, a dst naming an existing directory receives src under its own name.
+func MoveFile(src, dst string) error {
+	src = filepath.Clean(src)
+	dst = resolveTarget(src, dst)
+	if _, err := os.Lstat(src); err != nil {
+		return err
+	}
+	if err := checkDistinct(src, dst); err != nil {
+		return err
+	}
+
+	moved := false
+	err := updateStores([]string{filepath.Dir(dst), filepath.Dir(src)}, func(stores []store.Store) error {
+		if err := renameFile(src, dst); err != nil {
+			return err
+		}
+		moved = true
+		return transferAnnotation(stores[1], filepath.Base(src), stores[0], filepath.Base(dst), true)
+	})
+	if err != nil && moved {
+		// Put the file back where its annotation still is
+		if undoErr := renameFile(dst, src); undoErr != nil {
+			return fmt.Errorf("%w; moving %s back also failed: %v", err, dst, undoErr)
+		}
+	}
+	return err
+}
+
+// renameFile renames src to dst. Across file systems, where a rename is not
+// possible, a regular file is copied with its mode and times and then
+// removed, and a symlink is recreated.
+func renameFile(src, dst string) error {
+	err := os.Rename(src, dst)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	stat, statErr := os.Lstat(src)
+	if statErr != nil {
+		return statErr
+	}
+	switch {
+	case stat.Mode().IsRegular():
+		accessed := GetInfoFromFileSystem(src).lastVisitedTime // Before copying reads the file
+		if accessed.IsZero() {
+			accessed = stat.ModTime()
+		}
+		if err := copyContents(src, dst, stat.Mode().Perm()); err != nil {
+			os.Remove(dst)
+			return err
+		}
+		if err := os.Chmod(dst, stat.Mode()); err != nil {
+			os.Remove(dst)
+			return err
+		}
+		if err := os.Chtimes(dst, accessed, stat.ModTime()); err != nil {
+			os.Remove(dst)
+			return err
+		}
+	case stat.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		if err := os.Symlink(target, dst); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("cannot move %s to another file system: it is not a regular file or symlink", src)
+	}
+	if err := os.Remove(src); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return nil
+}
+
+// CopyFile copies the regular file src to dst and duplicates its annotation.
+func CopyFile(src, dst string) error {
+	src = filepath.Clean(src)
+	dst = resolveTarget(src, dst)
+	stat, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if stat.IsDir() {
+		return fmt.Errorf("%s is a directory", src)
+	}
+	if err := checkDistinct(src, dst); err != nil {
+		return err
+	}
+
+	return updateStores([]string{filepath.Dir(dst), filepath.Dir(src)}, func(stores []store.Store) error {
+		if err := copyContents(src, dst, stat.Mode().Perm()); err != nil {
+			return err
+		}
+		return transferAnnotation(stores[1], filepath.Base(src), stores[0], filepath.Base(dst), false)
+	})
+}
+
+// resolveTarget returns the path src ends up at when moved or copied to dst.
+func resolveTarget(src, dst string) string {
+	dst = filepath.Clean(dst)
+	if stat, err := os.Stat(dst); err == nil && stat.IsDir() {
+		return filepath.Join(dst, filepath.Base(src))
+	}
+	return dst
+}
+
+// checkDistinct refuses a dst that is src itself, for example "." or a hard
+// link to it: copying would truncate the file and moving would drop its
+// annotation.
+func checkDistinct(src, dst string) error {
+	srcStat, err := os.Stat(src)
+	if err != nil {
+		return nil // A dangling symlink is moved as it is
+	}
+	if dstStat, err := os.Stat(dst); err == nil && os.SameFile(srcStat, dstStat) {
+		return fmt.Errorf("%s and %s are the same file", src, dst)
+	}
+	return nil
+}
+
+// transferAnnotation copies the annotation of srcName in from to dstName in
+// to, removing it from from when move is set. A file without an annotation
+// clears any stale annotation left under dstName.
+func transferAnnotation(from store.Store, srcName string, to store.Store, dstName string, move bool) error {
+	info, found := from.Get(srcName)
+	if move {
+		if err := from.Delete(srcName); err != nil {
+			return err
+		}
+	}
+	if !found {
+		return to.Delete(dstName)
+	}
+	info.Name = dstName
+	return to.Put(info)
+}
+
+func copyContents(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
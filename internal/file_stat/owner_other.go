@@ -0,0 +1,10 @@
+//go:build !unix
+
+package file_stat
+
+import "os"
+
+// fileOwner is not supported on this platform.
+func fileOwner(info os.FileInfo) (uid, gid string, ok bool) {
+	return "", "", false
+}
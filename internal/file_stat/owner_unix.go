@@ -0,0 +1,19 @@
+//go:build unix
+
+package file_stat
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// fileOwner returns the ids of the user and group owning the file described
+// by info.
+func fileOwner(info os.FileInfo) (uid, gid string, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", "", false
+	}
+	return strconv.FormatUint(uint64(stat.Uid), 10), strconv.FormatUint(uint64(stat.Gid), 10), true
+}
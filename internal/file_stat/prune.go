@@ -0,0 +1,60 @@
+package file_stat
+
+import (
+	"os"
+
+	"lanno/internal/store"
+)
+
+// PruneReport lists the orphaned annotations of a directory, i.e. entries of
+// its .lanno.json whose file no longer exists.
+type PruneReport struct {
+	Directory string           `json:"directory"`
+	Orphans   []store.FileInfo `json:"orphans"`
+	Removed   bool             `json:"removed"`
+}
+
+// Prune finds the orphaned annotations of dir and deletes them from the store
+// when remove is set. The store is only rewritten if something is removed.
+func Prune(dir string, remove bool) (PruneReport, error) {
+	report := PruneReport{Directory: dir, Orphans: []store.FileInfo{}}
+
+	orphans, err := findOrphans(dir, OpenStore(dir))
+	if err != nil || len(orphans) == 0 || !remove {
+		report.Orphans = append(report.Orphans, orphans...)
+		return report, err
+	}
+
+	err = UpdateStore(dir, func(s store.Store) error {
+		// Look again now that the store is locked
+		orphans, err := findOrphans(dir, s)
+		if err != nil {
+			return err
+		}
+		for _, info := range orphans {
+			if err := s.Delete(info.Name); err != nil {
+				return err
+			}
+		}
+		report.Orphans = append(report.Orphans, orphans...)
+		return nil
+	})
+	report.Removed = err == nil
+	return report, err
+}
+
+// findOrphans loads s and compares its annotations against the entries of dir.
+func findOrphans(dir string, s store.Store) ([]store.FileInfo, error) {
+	if err := s.Load(); err != nil {
+		return nil, err
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	infos := make(map[string]store.FileInfo)
+	for _, info := range s.List() {
+		infos[info.Name] = info
+	}
+	return OrphanedEntries(infos, files), nil
+}
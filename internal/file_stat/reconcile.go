@@ -0,0 +1,209 @@
+package file_stat
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"lanno/internal/store"
+)
+
+// Fingerprint records the size, modification time and content hash of the
+// regular file at path in info. The stored hash is reused when size and
+// modification time are unchanged. Directories and other non-regular files
+// get no fingerprint, and neither do empty files: they all share one hash, so
+// it would match any empty file to any other.
+func Fingerprint(info *store.FileInfo, path string) error {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !stat.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", path)
+	}
+	if stat.Size() == 0 {
+		info.Hash, info.Size, info.ModTime = "", 0, ""
+		return nil
+	}
+	modTime := stat.ModTime().UTC().Format(time.RFC3339Nano)
+	if info.Hash != "" && info.Size == stat.Size() && info.ModTime == modTime {
+		return nil
+	}
+	hash, err := hashFile(path)
+	if err != nil {
+		return err
+	}
+	info.Hash = hash
+	info.Size = stat.Size()
+	info.ModTime = modTime
+	return nil
+}
+
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Rename is an orphaned annotation matched to an unannotated file with the
+// same content.
+type Rename struct {
+	From string // Path the annotation is stored under
+	To   string // Path of the file with the same content
+}
+
+// FindRenames matches the orphaned annotations under root against the
+// unannotated regular files under root by content hash. With recursive set,
+// all non-hidden subdirectories are searched as well, so moves between
+// directories are found. Orphans matching more than one file are skipped.
+func FindRenames(root string, recursive bool) ([]Rename, error) {
+	dirs := []string{filepath.Clean(root)}
+	if recursive {
+		dirs = dirs[:0]
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() {
+				return nil
+			}
+			if path != root && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			dirs = append(dirs, path)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Orphans by size and hash, and the unannotated files that could match them
+	type candidate struct {
+		path string
+		size int64
+	}
+	orphans := make(map[int64]map[string][]string)
+	var candidates []candidate
+	for _, dir := range dirs {
+		s := OpenStore(dir)
+		orphaned, err := findOrphans(dir, s)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range orphaned {
+			if info.Hash == "" || info.Size == 0 {
+				continue // Empty files recorded by older versions match any empty file
+			}
+			if orphans[info.Size] == nil {
+				orphans[info.Size] = make(map[string][]string)
+			}
+			orphans[info.Size][info.Hash] = append(orphans[info.Size][info.Hash], filepath.Join(dir, info.Name))
+		}
+
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if strings.HasPrefix(file.Name(), ".") || !file.Type().IsRegular() {
+				continue
+			}
+			if _, annotated := s.Get(file.Name()); annotated {
+				continue
+			}
+			stat, err := file.Info()
+			if err != nil {
+				continue
+			}
+			candidates = append(candidates, candidate{filepath.Join(dir, file.Name()), stat.Size()})
+		}
+	}
+
+	// Only hash the files whose size matches some orphan
+	matches := make(map[string][]string)
+	for _, c := range candidates {
+		if orphans[c.size] == nil {
+			continue
+		}
+		hash, err := hashFile(c.path)
+		if err != nil {
+			continue
+		}
+		for _, from := range orphans[c.size][hash] {
+			matches[from] = append(matches[from], c.path)
+		}
+	}
+
+	var renames []Rename
+	claimed := make(map[string]int)
+	for _, bySize := range orphans {
+		for _, froms := range bySize {
+			for _, from := range froms {
+				if len(matches[from]) == 1 {
+					renames = append(renames, Rename{From: from, To: matches[from][0]})
+					claimed[matches[from][0]]++
+				}
+			}
+		}
+	}
+
+	// Identical files annotated under several names cannot be told apart
+	unique := renames[:0]
+	for _, r := range renames {
+		if claimed[r.To] == 1 {
+			unique = append(unique, r)
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool {
+		return unique[i].From < unique[j].From
+	})
+	return unique, nil
+}
+
+// ApplyRenames re-keys the annotations of renames to their new paths. Each
+// rename is checked again under the store locks and skipped if the orphan is
+// gone or the new file has been annotated in the meantime.
+func ApplyRenames(renames []Rename) error {
+	var dirs []string
+	for _, r := range renames {
+		dirs = append(dirs, filepath.Dir(r.To), filepath.Dir(r.From))
+	}
+	return updateStores(dirs, func(stores []store.Store) error {
+		for i, r := range renames {
+			to, from := stores[2*i], stores[2*i+1]
+			if _, err := os.Lstat(r.From); err == nil {
+				continue
+			}
+			if _, ok := from.Get(filepath.Base(r.From)); !ok {
+				continue
+			}
+			if _, ok := to.Get(filepath.Base(r.To)); ok {
+				continue
+			}
+			if err := transferAnnotation(from, filepath.Base(r.From), to, filepath.Base(r.To), true); err != nil {
+				return err
+			}
+			info, _ := to.Get(filepath.Base(r.To))
+			if Fingerprint(&info, r.To) == nil {
+				to.Put(info)
+			}
+		}
+		return nil
+	})
+}
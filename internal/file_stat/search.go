@@ -0,0 +1,196 @@
+package file_stat
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"lanno/internal/fuzzy"
+	"lanno/internal/query"
+	"lanno/internal/table"
+)
+
+// searchKind selects how the search query of the interactive browser is
+// matched against the rows.
+type searchKind int
+
+const (
+	searchFuzzy searchKind = iota // fzf-style matching, best matches first
+	searchExact                   // Substrings and the query language of lanno find
+	searchRegex                   // Regular expression
+	searchKinds                   // Number of search kinds
+)
+
+var searchKindNames = [searchKinds]string{"fuzzy", "exact", "regex"}
+
+func (k searchKind) String() string {
+	return searchKindNames[k]
+}
+
+// next returns the search kind the tab key switches to.
+func (k searchKind) next() searchKind {
+	return (k + 1) % searchKinds
+}
+
+// searchField is the searchable text of one column of a row.
+type searchField struct {
+	key    string // Column key
+	text   string // Text to match
+	offset int    // Rune offset of text in the cell value
+}
+
+// searchFields returns the full file name, tags and description of row,
+// taken from its Entry rather than from the cells.
+func searchFields(row table.Row) []searchField {
+	entry, ok := rowEntry(row)
+	if !ok {
+		return nil
+	}
+	name := entry.Info.Name
+	cell := fmt.Sprintf("%v", row.Data[columnKeyFilename]) // Icon, space and name
+	return []searchField{
+		{columnKeyFilename, name, utf8.RuneCountInString(cell) - utf8.RuneCountInString(name)},
+		{columnKeyTags, strings.Join(entry.Info.Tags, ", "), 0},
+		{columnKeyDescription, entry.Info.Description, 0},
+	}
+}
+
+// filterRows keeps the rows matching queryText in the given kind of search
+// and marks the matched characters. Fuzzy results are sorted by score. A query
+// that does not parse is returned as an error.
+func filterRows(rows []table.Row, queryText string, kind searchKind) ([]table.Row, error) {
+	switch kind {
+	case searchFuzzy:
+		return fuzzyFilter(rows, queryText), nil
+	case searchRegex:
+		return regexFilter(rows, queryText)
+	default:
+		return queryFilter(rows, queryText)
+	}
+}
+
+// fuzzyFilter keeps the rows in which every space-separated term of
+// queryText fuzzy-matches the name, tags or description.
+func fuzzyFilter(rows []table.Row, queryText string) []table.Row {
+	terms := strings.Fields(queryText)
+	if len(terms) == 0 {
+		return rows
+	}
+
+	type result struct {
+		row   table.Row
+		score int
+	}
+	var results []result
+	for _, row := range rows {
+		fields := searchFields(row)
+		highlights := map[string][]int{}
+		total := 0
+		matched := true
+		for _, term := range terms {
+			// Each term counts where it matches best
+			best, bestField, bestPositions := 0, -1, []int(nil)
+			for i, field := range fields {
+				if score, positions, ok := fuzzy.Match(term, field.text); ok && (bestField < 0 || score > best) {
+					best, bestField, bestPositions = score, i, positions
+				}
+			}
+			if bestField < 0 {
+				matched = false
+				break
+			}
+			total += best
+			field := fields[bestField]
+			for _, position := range bestPositions {
+				highlights[field.key] = append(highlights[field.key], field.offset+position)
+			}
+		}
+		if matched {
+			row.Highlights = highlights
+			results = append(results, result{row, total})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].score > results[j].score
+	})
+	filtered := make([]table.Row, len(results))
+	for i, result := range results {
+		filtered[i] = result.row
+	}
+	return filtered
+}
+
+// regexFilter keeps the rows whose name, tags or description match the
+// regular expression queryText. Like the fuzzy search it ignores case unless
+// queryText contains an upper-case letter.
+func regexFilter(rows []table.Row, queryText string) ([]table.Row, error) {
+	if queryText == "" {
+		return rows, nil
+	}
+	if strings.IndexFunc(queryText, unicode.IsUpper) < 0 {
+		queryText = "(?i)" + queryText
+	}
+	re, err := regexp.Compile(queryText)
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered []table.Row
+	for _, row := range rows {
+		highlights := map[string][]int{}
+		matched := false
+		for _, field := range searchFields(row) {
+			for _, loc := range re.FindAllStringIndex(field.text, -1) {
+				matched = true
+				start := utf8.RuneCountInString(field.text[:loc[0]])
+				end := start + utf8.RuneCountInString(field.text[loc[0]:loc[1]])
+				for position := start; position < end; position++ {
+					highlights[field.key] = append(highlights[field.key], field.offset+position)
+				}
+			}
+		}
+		if matched {
+			row.Highlights = highlights
+			filtered = append(filtered, row)
+		}
+	}
+	return filtered, nil
+}
+
+// queryFilter keeps the rows matching queryText; see package query for the
+// syntax.
+func queryFilter(rows []table.Row, queryText string) ([]table.Row, error) {
+	expr, err := query.Parse(queryText)
+	if err != nil {
+		return nil, err
+	}
+	var filtered []table.Row
+	for _, row := range rows {
+		entry, ok := rowEntry(row)
+		if !ok {
+			continue
+		}
+		if expr.Match(entry.QueryItem()) {
+			filtered = append(filtered, row)
+		}
+	}
+	return filtered, nil
+}
+
+// applySearch filters the table by the search query and selects the first,
+// best matching row. While the query does not parse, for example halfway
+// through typing it, the previous results stay.
+func (m FileModel) applySearch() FileModel {
+	filtered, err := filterRows(m.allRows, m.searchQuery, m.searchKind)
+	m.searchErr = err
+	if err == nil {
+		m.table = m.table.WithRows(filtered)
+		m.table.Selected = 0
+		m.table.SetPage(0)
+	}
+	return m
+}
@@ -0,0 +1,18 @@
+//go:build darwin
+
+package file_stat
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// statTimes returns the access and birth times recorded in info.
+func statTimes(path string, info os.FileInfo) (accessed, created time.Time) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, time.Time{}
+	}
+	return time.Unix(stat.Atimespec.Unix()), time.Unix(stat.Birthtimespec.Unix())
+}
@@ -0,0 +1,33 @@
+//go:build linux
+
+package file_stat
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// statTimes returns the access and birth times of the file at path. The
+// birth time comes from statx and is zero on kernels or file systems that
+// do not record it.
+func statTimes(path string, info os.FileInfo) (accessed, created time.Time) {
+	var stx unix.Statx_t
+	err := unix.Statx(unix.AT_FDCWD, path, unix.AT_SYMLINK_NOFOLLOW, unix.STATX_ATIME|unix.STATX_BTIME, &stx)
+	if err != nil {
+		// No statx, for example before Linux 4.11: the access time is in info
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			accessed = time.Unix(stat.Atim.Unix())
+		}
+		return accessed, time.Time{}
+	}
+	if stx.Mask&unix.STATX_ATIME != 0 {
+		accessed = time.Unix(stx.Atime.Sec, int64(stx.Atime.Nsec))
+	}
+	if stx.Mask&unix.STATX_BTIME != 0 {
+		created = time.Unix(stx.Btime.Sec, int64(stx.Btime.Nsec))
+	}
+	return accessed, created
+}
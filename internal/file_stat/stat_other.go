@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package file_stat
+
+import (
+	"os"
+	"time"
+)
+
+// statTimes is not supported on this platform; only the modification time
+// is known.
+func statTimes(path string, info os.FileInfo) (accessed, created time.Time) {
+	return time.Time{}, time.Time{}
+}
@@ -0,0 +1,104 @@
+package file_stat
+
+import (
+	"time"
+
+	"lanno/internal/table"
+)
+
+// timeColumns selects the timestamp columns shown in the browser. The T key
+// cycles through them.
+type timeColumns int
+
+const (
+	timesHidden   timeColumns = iota // No timestamps
+	timesModified                    // Modification time only
+	timesAll                         // Created, modified and accessed times
+	timeColumnModes
+)
+
+// next returns the time columns the T key switches to.
+func (t timeColumns) next() timeColumns {
+	return (t + 1) % timeColumnModes
+}
+
+// keys returns the column keys shown for t, in display order.
+func (t timeColumns) keys() []string {
+	switch t {
+	case timesModified:
+		return []string{columnKeyUpdatedTime}
+	case timesAll:
+		return []string{columnKeyCreatedTime, columnKeyUpdatedTime, columnKeyVisitedTime}
+	default:
+		return nil
+	}
+}
+
+// timeLayout is how timestamps are shown in the browser.
+const timeLayout = "2006-01-02 15:04"
+
+// timeColumnTitles are the headers of the timestamp columns.
+var timeColumnTitles = map[string]string{
+	columnKeyCreatedTime: "Created",
+	columnKeyUpdatedTime: "Modified",
+	columnKeyVisitedTime: "Accessed",
+}
+
+// fileTimes returns the created, modified and accessed times of the file at
+// path. Times the platform does not record are zero.
+func fileTimes(path string) (created, modified, accessed time.Time) {
+	stat := GetInfoFromFileSystem(path)
+	return stat.createTime, stat.lastUpdatedTime, stat.lastVisitedTime
+}
+
+// formatTime renders t for a timestamp column, or "" when it is unknown.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format(timeLayout)
+}
+
+// addTimes fills in the timestamp cells shown for mode. Only the shown times
+// are looked up, since the created and accessed times cost a stat call each.
+func addTimes(rows []table.Row, mode timeColumns) {
+	keys := mode.keys()
+	if len(keys) == 0 {
+		return
+	}
+	for _, row := range rows {
+		entry, ok := rowEntry(row)
+		if !ok || entry.Missing {
+			continue
+		}
+		if mode == timesModified {
+			row.Data[columnKeyUpdatedTime] = formatTime(entryModTime(entry))
+			continue
+		}
+		created, modified, accessed := fileTimes(entry.Path)
+		row.Data[columnKeyCreatedTime] = formatTime(created)
+		row.Data[columnKeyUpdatedTime] = formatTime(modified)
+		row.Data[columnKeyVisitedTime] = formatTime(accessed)
+	}
+}
+
+// entryModTime returns the modification time of the entry's file, or zero
+// when it is missing.
+func entryModTime(entry Entry) time.Time {
+	if entry.DirEntry == nil {
+		return time.Time{}
+	}
+	info, err := entry.DirEntry.Info()
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// withTimeColumns appends the timestamp columns shown for mode.
+func withTimeColumns(columns []table.Column, mode timeColumns) []table.Column {
+	for _, key := range mode.keys() {
+		columns = append(columns, table.NewColumn(key, timeColumnTitles[key], len(timeLayout)).WithMinWidth(len(timeLayout)))
+	}
+	return columns
+}
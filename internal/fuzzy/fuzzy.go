@@ -0,0 +1,180 @@
+package fuzzy
+
+import "unicode"
+
+// Scores follow fzf: every matched character earns scoreMatch, gaps between
+// matched characters cost, and characters at word boundaries or right after
+// another matched character earn a bonus.
+const (
+	scoreMatch        = 16
+	scoreGapStart     = -3
+	scoreGapExtension = -1
+
+	bonusBoundary    = scoreMatch / 2 // After a delimiter or space, or at the start
+	bonusNonWord     = scoreMatch / 2 // The matched character is a delimiter itself
+	bonusCamel123    = bonusBoundary + scoreGapExtension
+	bonusConsecutive = -(scoreGapStart + scoreGapExtension)
+
+	bonusFirstCharMultiplier = 2
+)
+
+// charClass groups characters for the boundary bonuses.
+type charClass int
+
+const (
+	classDelimiter charClass = iota // Whitespace and / , : ; | _ - .
+	classNonWord
+	classLower
+	classUpper
+	classNumber
+)
+
+func classOf(r rune) charClass {
+	switch {
+	case unicode.IsLower(r):
+		return classLower
+	case unicode.IsUpper(r):
+		return classUpper
+	case unicode.IsDigit(r):
+		return classNumber
+	case unicode.IsSpace(r) || r == '/' || r == ',' || r == ':' || r == ';' || r == '|' || r == '_' || r == '-' || r == '.':
+		return classDelimiter
+	case unicode.IsLetter(r):
+		return classLower
+	default:
+		return classNonWord
+	}
+}
+
+// bonusFor returns the bonus of a character of class class following one of
+// class prev.
+func bonusFor(prev, class charClass) int {
+	switch {
+	case class == classDelimiter || class == classNonWord:
+		return bonusNonWord
+	case prev == classDelimiter || prev == classNonWord:
+		return bonusBoundary
+	case prev == classLower && class == classUpper, prev != classNumber && class == classNumber:
+		return bonusCamel123
+	default:
+		return 0
+	}
+}
+
+// Match reports whether the characters of pattern occur in text in order,
+// like "mnhdlr" in "main_handler.go". The match is case-insensitive unless
+// pattern contains an upper-case letter. score ranks matches against each
+// other, higher is better; positions are the indexes of the matched runes of
+// text.
+func Match(pattern, text string) (score int, positions []int, ok bool) {
+	p := []rune(pattern)
+	t := []rune(text)
+	if len(p) == 0 {
+		return 0, nil, true
+	}
+	fold := !hasUpper(p)
+	equal := func(a, b rune) bool {
+		if fold {
+			a = unicode.ToLower(a)
+		}
+		return a == b
+	}
+
+	// Find the end of the first occurrence scanning forward...
+	pi, end := 0, -1
+	for i, r := range t {
+		if equal(r, p[pi]) {
+			pi++
+			if pi == len(p) {
+				end = i
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+
+	// ...then the latest start of an occurrence ending there, which gives
+	// the shortest window containing the pattern.
+	pi, start := len(p)-1, end
+	for i := end; i >= 0; i-- {
+		if equal(t[i], p[pi]) {
+			pi--
+			if pi < 0 {
+				start = i
+				break
+			}
+		}
+	}
+
+	score, positions = calculateScore(t, p, start, end, equal)
+	return score, positions, true
+}
+
+// calculateScore scores the match of p in t[start:end+1].
+func calculateScore(t, p []rune, start, end int, equal func(a, b rune) bool) (int, []int) {
+	prevClass := classDelimiter
+	if start > 0 {
+		prevClass = classOf(t[start-1])
+	}
+
+	score, pi := 0, 0
+	consecutive, firstBonus, inGap := 0, 0, false
+	positions := make([]int, 0, len(p))
+	for i := start; i <= end && pi < len(p); i++ {
+		class := classOf(t[i])
+		if equal(t[i], p[pi]) {
+			positions = append(positions, i)
+			score += scoreMatch
+			bonus := bonusFor(prevClass, class)
+			if consecutive == 0 {
+				firstBonus = bonus
+			} else {
+				// A run of matches keeps the bonus of its first character
+				if bonus >= bonusBoundary && bonus > firstBonus {
+					firstBonus = bonus
+				}
+				bonus = maxInt(bonus, firstBonus, bonusConsecutive)
+			}
+			if pi == 0 {
+				score += bonus * bonusFirstCharMultiplier
+			} else {
+				score += bonus
+			}
+			inGap = false
+			consecutive++
+			pi++
+		} else {
+			if inGap {
+				score += scoreGapExtension
+			} else {
+				score += scoreGapStart
+			}
+			inGap = true
+			consecutive = 0
+			firstBonus = 0
+		}
+		prevClass = class
+	}
+	return score, positions
+}
+
+func hasUpper(runes []rune) bool {
+	for _, r := range runes {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func maxInt(values ...int) int {
+	result := values[0]
+	for _, v := range values[1:] {
+		if v > result {
+			result = v
+		}
+	}
+	return result
+}
@@ -0,0 +1,347 @@
+package query
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+)
+
+//------------------------------------------------------------------------------
+// Expressions
+//------------------------------------------------------------------------------
+
+// Item is what a query is matched against.
+type Item struct {
+	Name        string   // File name
+	Tags        []string // Tags including the leading '#'
+	Description string   // Free-form description
+}
+
+// Expr is a parsed query.
+type Expr interface {
+	Match(item Item) bool
+}
+
+// Fields that terms can be restricted to with <field>:<value>
+const (
+	FieldAny  = ""     // Bare term: name, tags or description
+	FieldTag  = "tag"  // Exact tag, or glob over tags
+	FieldDesc = "desc" // Substring or glob of the description
+	FieldName = "name" // Substring or glob of the file name
+)
+
+// fieldAliases maps every accepted field prefix to its field.
+var fieldAliases = map[string]string{
+	"tag":         FieldTag,
+	"tags":        FieldTag,
+	"desc":        FieldDesc,
+	"description": FieldDesc,
+	"name":        FieldName,
+}
+
+// Term matches a single value, optionally restricted to one field. Values
+// containing *, ? or [ are globs; otherwise names and descriptions match by
+// substring and tags must match exactly. Matching ignores case.
+type Term struct {
+	Field string
+	Value string
+}
+
+// Match implements Expr.
+func (t Term) Match(item Item) bool {
+	value := strings.ToLower(t.Value)
+	switch t.Field {
+	case FieldTag:
+		value = strings.TrimPrefix(value, "#")
+		for _, tag := range item.Tags {
+			tag = strings.TrimPrefix(strings.ToLower(tag), "#")
+			if isGlob(value) && globMatch(value, tag) || tag == value {
+				return true
+			}
+		}
+		return false
+	case FieldDesc:
+		return textMatch(value, item.Description)
+	case FieldName:
+		return textMatch(value, item.Name)
+	default:
+		return textMatch(value, item.Name) ||
+			textMatch(value, strings.Join(item.Tags, " ")) ||
+			textMatch(value, item.Description)
+	}
+}
+
+// And matches when both sides match.
+type And struct{ Left, Right Expr }
+
+// Match implements Expr.
+func (e And) Match(item Item) bool { return e.Left.Match(item) && e.Right.Match(item) }
+
+// Or matches when either side matches.
+type Or struct{ Left, Right Expr }
+
+// Match implements Expr.
+func (e Or) Match(item Item) bool { return e.Left.Match(item) || e.Right.Match(item) }
+
+// Not matches when its operand does not.
+type Not struct{ Operand Expr }
+
+// Match implements Expr.
+func (e Not) Match(item Item) bool { return !e.Operand.Match(item) }
+
+// All matches every item; it is the result of parsing an empty query.
+type All struct{}
+
+// Match implements Expr.
+func (All) Match(Item) bool { return true }
+
+func isGlob(value string) bool {
+	return strings.ContainsAny(value, "*?[")
+}
+
+// globMatch is path.Match with malformed patterns matching nothing.
+func globMatch(pattern, text string) bool {
+	matched, err := path.Match(pattern, text)
+	return err == nil && matched
+}
+
+// textMatch matches the lowercase value against text by glob or substring.
+func textMatch(value, text string) bool {
+	text = strings.ToLower(text)
+	if isGlob(value) {
+		return globMatch(value, text)
+	}
+	return strings.Contains(text, value)
+}
+
+//------------------------------------------------------------------------------
+// Parsing
+//------------------------------------------------------------------------------
+
+// Parse parses a query such as
+//
+//	tag:backend AND NOT tag:deprecated
+//	desc:"entry point" OR (name:*.go tag:handler)
+//
+// AND, OR and NOT must be upper case. Adjacent terms are joined with AND, NOT
+// binds tighter than AND, and AND binds tighter than OR.
+func Parse(query string) (Expr, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return All{}, nil
+	}
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, p.errorf("unexpected %s", p.peek())
+	}
+	return expr, nil
+}
+
+type tokenKind int
+
+const (
+	tokenTerm tokenKind = iota
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenOpen
+	tokenClose
+)
+
+type token struct {
+	kind tokenKind
+	term Term
+	pos  int // Byte offset in the query, for error messages
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenAnd:
+		return "AND"
+	case tokenOr:
+		return "OR"
+	case tokenNot:
+		return "NOT"
+	case tokenOpen:
+		return `"("`
+	case tokenClose:
+		return `")"`
+	default:
+		return fmt.Sprintf("%q", t.term.Value)
+	}
+}
+
+// tokenize splits a query into parentheses, operators and terms.
+func tokenize(query string) ([]token, error) {
+	var tokens []token
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		switch r := runes[i]; {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenOpen, pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenClose, pos: i})
+			i++
+		default:
+			start := i
+			word, quoted, next, err := readWord(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			i = next
+			tokens = append(tokens, wordToken(word, quoted, start))
+		}
+	}
+	return tokens, nil
+}
+
+// readWord reads a term starting at i. A term is a run of characters up to
+// whitespace or a parenthesis; double-quoted sections may contain either.
+func readWord(runes []rune, i int) (word string, quoted bool, next int, err error) {
+	var b strings.Builder
+	for i < len(runes) {
+		r := runes[i]
+		if unicode.IsSpace(r) || r == '(' || r == ')' {
+			break
+		}
+		if r != '"' {
+			b.WriteRune(r)
+			i++
+			continue
+		}
+		quoted = true
+		end := i + 1
+		for end < len(runes) && runes[end] != '"' {
+			end++
+		}
+		if end == len(runes) {
+			return "", false, 0, fmt.Errorf("unterminated quote at position %d", i+1)
+		}
+		b.WriteString(string(runes[i+1 : end]))
+		i = end + 1
+	}
+	return b.String(), quoted, i, nil
+}
+
+// wordToken turns a word into an operator or a possibly field-restricted term.
+func wordToken(word string, quoted bool, pos int) token {
+	if !quoted {
+		switch word {
+		case "AND":
+			return token{kind: tokenAnd, pos: pos}
+		case "OR":
+			return token{kind: tokenOr, pos: pos}
+		case "NOT":
+			return token{kind: tokenNot, pos: pos}
+		}
+	}
+	if prefix, value, ok := strings.Cut(word, ":"); ok {
+		if field, known := fieldAliases[strings.ToLower(prefix)]; known {
+			return token{kind: tokenTerm, term: Term{Field: field, Value: value}, pos: pos}
+		}
+	}
+	return token{kind: tokenTerm, term: Term{Field: FieldAny, Value: word}, pos: pos}
+}
+
+type parser struct {
+	tokens []token
+	next   int
+}
+
+func (p *parser) done() bool { return p.next >= len(p.tokens) }
+
+func (p *parser) peek() token { return p.tokens[p.next] }
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	if p.done() {
+		return fmt.Errorf(format+" at end of query", args...)
+	}
+	return fmt.Errorf(format+" at position %d", append(args, p.peek().pos+1)...)
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for !p.done() && p.peek().kind == tokenOr {
+		p.next++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for !p.done() {
+		switch p.peek().kind {
+		case tokenAnd:
+			p.next++
+		case tokenTerm, tokenNot, tokenOpen:
+			// Adjacent terms are implicitly joined with AND
+		default:
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = And{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	if !p.done() && p.peek().kind == tokenNot {
+		p.next++
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return Not{operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	if p.done() {
+		return nil, p.errorf("expected a term")
+	}
+	tok := p.peek()
+	switch tok.kind {
+	case tokenTerm:
+		p.next++
+		return tok.term, nil
+	case tokenOpen:
+		p.next++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.done() || p.peek().kind != tokenClose {
+			return nil, p.errorf(`expected ")"`)
+		}
+		p.next++
+		return expr, nil
+	default:
+		return nil, p.errorf("unexpected %s", tok)
+	}
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Locker is implemented by stores that can be locked against concurrent
+// writers for the duration of a Load, modify, Save cycle.
+type Locker interface {
+	Lock() (unlock func() error, err error)
+}
+
+// lockTimeout bounds how long the lock-file fallback waits for another
+// writer before giving up.
+const lockTimeout = 10 * time.Second
+
+// Lock takes an exclusive advisory lock on <path>.lock. It waits until no
+// other process or goroutine holds the lock.
+func (s *JSONStore) Lock() (func() error, error) {
+	return lockFile(s.path + ".lock")
+}
+
+// lockExclusive takes the lock by creating the lock file with O_EXCL and
+// releases it by removing the file. It is used where flock is unavailable.
+func lockExclusive(path string) (func() error, error) {
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			file.Close()
+			return func() error { return os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for lock %s; remove it if no lanno process is running", path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
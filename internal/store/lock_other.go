@@ -0,0 +1,8 @@
+//go:build !unix
+
+package store
+
+// lockFile uses an exclusive lock file on platforms without flock.
+func lockFile(path string) (func() error, error) {
+	return lockExclusive(path)
+}
@@ -0,0 +1,36 @@
+//go:build unix
+
+package store
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockFile takes a flock on path, which is created if needed and left in
+// place afterwards. Filesystems without flock support fall back to an
+// exclusive lock file.
+func lockFile(path string) (func() error, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		file.Close()
+		if errors.Is(err, syscall.ENOTSUP) || errors.Is(err, syscall.EOPNOTSUPP) || errors.Is(err, syscall.ENOLCK) {
+			return lockExclusive(path + ".excl")
+		}
+		return nil, err
+	}
+	return func() error {
+		defer file.Close()
+		return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+	}, nil
+}
@@ -0,0 +1,169 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SchemaVersion is the version of the .lanno.json layout written by this
+// build. Files without a version field are version 0.
+const SchemaVersion = 1
+
+// ErrNewerSchema is returned when saving a file that was written by a newer
+// lanno. Such files can be read but are never overwritten.
+var ErrNewerSchema = errors.New("annotation file was written by a newer lanno")
+
+// migrations[i] upgrades the raw top-level fields of a version i file to
+// version i+1. Each step only needs to know about its own two versions.
+var migrations = []func(fields map[string]json.RawMessage) error{
+	migrateV0,
+}
+
+// migrateV0 strips the "./" prefix that early versions wrote in front of
+// file names.
+func migrateV0(fields map[string]json.RawMessage) error {
+	raw, ok := fields["file_info"]
+	if !ok || string(raw) == "null" {
+		return nil
+	}
+	var entries []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		var name string
+		if err := json.Unmarshal(entry["name"], &name); err != nil || !strings.HasPrefix(name, "./") {
+			continue
+		}
+		entry["name"], _ = json.Marshal(strings.TrimPrefix(name, "./"))
+	}
+	var err error
+	fields["file_info"], err = json.Marshal(entries)
+	return err
+}
+
+// decode parses the content of a .lanno.json file and runs the migrations
+// needed to bring it to SchemaVersion. Files from a newer version are
+// decoded as they are, with their version kept.
+func decode(content []byte) (LannoFileData, error) {
+	var data LannoFileData
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(content, &fields); err != nil {
+		return data, err
+	}
+	if fields == nil {
+		fields = map[string]json.RawMessage{} // A null file is an empty one
+	}
+
+	version := 0
+	if raw, ok := fields["version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return data, fmt.Errorf("invalid version: %v", err)
+		}
+		if version < 0 {
+			return data, fmt.Errorf("invalid version: %d", version)
+		}
+	}
+	for ; version < SchemaVersion; version++ {
+		if err := migrations[version](fields); err != nil {
+			return data, fmt.Errorf("upgrading from version %d: %v", version, err)
+		}
+	}
+	fields["version"], _ = json.Marshal(version)
+
+	content, err := json.Marshal(fields)
+	if err != nil {
+		return data, err
+	}
+	err = json.Unmarshal(content, &data)
+	return data, err
+}
+
+//------------------------------------------------------------------------------
+// Unknown Field Preservation
+//------------------------------------------------------------------------------
+
+// UnmarshalJSON decodes a file entry and keeps fields it does not know.
+func (f *FileInfo) UnmarshalJSON(content []byte) error {
+	type plain FileInfo
+	if err := json.Unmarshal(content, (*plain)(f)); err != nil {
+		return err
+	}
+	extra, err := unknownFields(content, reflect.TypeOf(plain{}))
+	f.Extra = extra
+	return err
+}
+
+// MarshalJSON encodes a file entry followed by its unknown fields.
+func (f FileInfo) MarshalJSON() ([]byte, error) {
+	type plain FileInfo
+	return marshalWithExtra(plain(f), f.Extra)
+}
+
+// UnmarshalJSON decodes a file and keeps top-level fields it does not know.
+func (d *LannoFileData) UnmarshalJSON(content []byte) error {
+	type plain LannoFileData
+	if err := json.Unmarshal(content, (*plain)(d)); err != nil {
+		return err
+	}
+	extra, err := unknownFields(content, reflect.TypeOf(plain{}))
+	d.Extra = extra
+	return err
+}
+
+// MarshalJSON encodes a file followed by its unknown top-level fields.
+func (d LannoFileData) MarshalJSON() ([]byte, error) {
+	type plain LannoFileData
+	return marshalWithExtra(plain(d), d.Extra)
+}
+
+// unknownFields returns the fields of the JSON object in content that have
+// no matching field in the struct type t.
+func unknownFields(content []byte, t reflect.Type) (map[string]json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(content, &fields); err != nil {
+		return nil, err
+	}
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		delete(fields, name)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return fields, nil
+}
+
+// marshalWithExtra encodes v, which must encode to a JSON object, and appends
+// the extra fields in name order.
+func marshalWithExtra(v interface{}, extra map[string]json.RawMessage) ([]byte, error) {
+	content, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return content, err
+	}
+
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	b.Write(content[:len(content)-1])
+	for _, name := range names {
+		key, _ := json.Marshal(name)
+		if b.Len() > 1 {
+			b.WriteByte(',')
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(extra[name])
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
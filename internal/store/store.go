@@ -0,0 +1,270 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrCorrupt is returned when a .lanno.json file exists but cannot be parsed.
+// Such a file is never overwritten, so no annotations are lost.
+var ErrCorrupt = errors.New("annotation file is corrupt")
+
+//------------------------------------------------------------------------------
+// Data Definitions
+//------------------------------------------------------------------------------
+
+// FileInfo holds the annotation of a single file.
+type FileInfo struct {
+	Name        string   `json:"name"`        // File name relative to the store's directory
+	Tags        []string `json:"tags"`        // Tags including the leading '#'
+	Description string   `json:"description"` // Free-form description
+
+	// Fingerprint of the file content, used to recognize renamed files
+	Hash    string `json:"hash,omitempty"`  // Hex SHA-256 of the content
+	Size    int64  `json:"size,omitempty"`  // Size in bytes when hashed
+	ModTime string `json:"mtime,omitempty"` // RFC 3339 modification time when hashed
+
+	// Fields written by newer versions of lanno, kept as they are
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// LannoFileData is the layout of a .lanno.json file.
+type LannoFileData struct {
+	Version  int        `json:"version"` // Schema version, see SchemaVersion
+	FileInfo []FileInfo `json:"file_info"`
+
+	// Fields written by newer versions of lanno, kept as they are
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+//------------------------------------------------------------------------------
+// Store Interface
+//------------------------------------------------------------------------------
+
+// Store is the set of annotations for the entries of one directory.
+//
+// Get, Put, Delete and List work on the in-memory state. Load replaces that
+// state with the contents of the backing storage and Save writes it back.
+type Store interface {
+	Load() error                      // Read annotations from the backing storage
+	Get(name string) (FileInfo, bool) // Look up the annotation of a file
+	Put(info FileInfo) error          // Add or replace the annotation of info.Name
+	Delete(name string) error         // Remove the annotation of a file
+	List() []FileInfo                 // All annotations in storage order
+	Save() error                      // Write annotations to the backing storage
+}
+
+//------------------------------------------------------------------------------
+// In-memory Store
+//------------------------------------------------------------------------------
+
+// MemoryStore is a Store without backing storage. Load and Save do nothing,
+// which makes it useful for tests.
+type MemoryStore struct {
+	entries []FileInfo
+}
+
+// NewMemoryStore creates a store holding the given annotations.
+func NewMemoryStore(entries ...FileInfo) *MemoryStore {
+	s := &MemoryStore{}
+	for _, info := range entries {
+		s.Put(info)
+	}
+	return s
+}
+
+// Load is a no-op for in-memory stores.
+func (s *MemoryStore) Load() error {
+	return nil
+}
+
+// Get returns the annotation stored under name.
+func (s *MemoryStore) Get(name string) (FileInfo, bool) {
+	if i := s.indexOf(name); i >= 0 {
+		return s.entries[i], true
+	}
+	return FileInfo{}, false
+}
+
+// Put adds or replaces the annotation of info.Name.
+func (s *MemoryStore) Put(info FileInfo) error {
+	if info.Tags == nil {
+		info.Tags = []string{}
+	}
+	if i := s.indexOf(info.Name); i >= 0 {
+		s.entries[i] = info
+	} else {
+		s.entries = append(s.entries, info)
+	}
+	return nil
+}
+
+// Delete removes the annotation stored under name, if any.
+func (s *MemoryStore) Delete(name string) error {
+	if i := s.indexOf(name); i >= 0 {
+		s.entries = append(s.entries[:i], s.entries[i+1:]...)
+	}
+	return nil
+}
+
+// List returns a copy of all annotations in insertion order.
+func (s *MemoryStore) List() []FileInfo {
+	return append([]FileInfo{}, s.entries...)
+}
+
+// Save is a no-op for in-memory stores.
+func (s *MemoryStore) Save() error {
+	return nil
+}
+
+func (s *MemoryStore) indexOf(name string) int {
+	for i, info := range s.entries {
+		if info.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+//------------------------------------------------------------------------------
+// JSON Store
+//------------------------------------------------------------------------------
+
+// JSONStore is a Store backed by a .lanno.json file.
+type JSONStore struct {
+	MemoryStore
+	path    string                     // Path of the .lanno.json file
+	backup  bool                       // Whether Save keeps the previous file as .lanno.json.bak
+	version int                        // Schema version of the file as loaded
+	extra   map[string]json.RawMessage // Unknown top-level fields of the file
+}
+
+// NewJSONStore creates a store for the .lanno.json file at path. Nothing is
+// read until Load is called.
+func NewJSONStore(path string) *JSONStore {
+	return &JSONStore{path: path}
+}
+
+// WithBackup sets whether Save copies the previous file to <path>.bak.
+func (s *JSONStore) WithBackup(backup bool) *JSONStore {
+	s.backup = backup
+	return s
+}
+
+// Path returns the location of the backing .lanno.json file.
+func (s *JSONStore) Path() string {
+	return s.path
+}
+
+// Load reads the .lanno.json file, upgrading older schema versions. A missing
+// or empty file holds no annotations; a file that is not valid JSON yields
+// ErrCorrupt.
+func (s *JSONStore) Load() error {
+	s.entries = nil
+	s.version = SchemaVersion
+	s.extra = nil
+
+	file, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	byteValue, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	if len(strings.TrimSpace(string(byteValue))) == 0 {
+		return nil
+	}
+
+	data, err := decode(byteValue)
+	if err != nil {
+		return fmt.Errorf("%s: %w: %v", s.path, ErrCorrupt, err)
+	}
+	for _, info := range data.FileInfo {
+		s.Put(info)
+	}
+	s.version = data.Version
+	s.extra = data.Extra
+	return nil
+}
+
+// Save writes all annotations to the .lanno.json file. The new content is
+// written to a temporary file, synced and renamed over the old file, so a
+// crash leaves either the old or the new version in place. Files written by
+// a newer schema version are never overwritten.
+func (s *JSONStore) Save() error {
+	if s.version > SchemaVersion {
+		return fmt.Errorf("%s: %w (file has version %d, this lanno supports up to %d)",
+			s.path, ErrNewerSchema, s.version, SchemaVersion)
+	}
+	data := LannoFileData{Version: SchemaVersion, FileInfo: s.List(), Extra: s.extra}
+	byteValue, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	perm := os.FileMode(0644)
+	if stat, err := os.Stat(s.path); err == nil {
+		perm = stat.Mode().Perm()
+		if s.backup {
+			previous, err := os.ReadFile(s.path)
+			if err != nil {
+				return err
+			}
+			if err := writeFileAtomic(s.path+".bak", previous, perm); err != nil {
+				return err
+			}
+		}
+	}
+	return writeFileAtomic(s.path, byteValue, perm)
+}
+
+// writeFileAtomic replaces the file at path with data through a synced
+// temporary file in the same directory.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) (err error) {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// Sync the directory so the rename itself survives a crash. Not every
+	// platform supports this, so failures are ignored.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
@@ -0,0 +1,413 @@
+package table
+
+import (
+	"fmt"
+
+	"github.com/mattn/go-runewidth"
+)
+
+//------------------------------------------------------------------------------
+// Column Layout
+//------------------------------------------------------------------------------
+
+// WithMinWidth is a chainable method to keep the column at least width wide
+// when the table has to fit a width.
+func (c Column) WithMinWidth(width int) Column {
+	c.MinWidth = width
+	return c
+}
+
+// WithMaxWidth is a chainable method to keep the column at most width wide
+// when the table has to fit a width.
+func (c Column) WithMaxWidth(width int) Column {
+	c.MaxWidth = width
+	return c
+}
+
+// WithFlex is a chainable method to give the column a share of the width
+// left over, in proportion to weight. Flexible columns also shrink first when
+// the table is too wide.
+func (c Column) WithFlex(weight int) Column {
+	c.Flex = weight
+	return c
+}
+
+// WithFit is a chainable method to make the column as wide as its title and
+// longest cell instead of Width.
+func (c Column) WithFit(fit bool) Column {
+	c.Fit = fit
+	return c
+}
+
+// Layout is how the columns of a table were rearranged by the user. It is
+// kept apart from the columns so that it survives rebuilding the table.
+type Layout struct {
+	Order  []string        // Column keys in display order; other columns follow in their own order
+	Hidden map[string]bool // Keys of the hidden columns
+	Widths map[string]int  // Widths set by resizing, by column key
+	Focus  string          // Key of the column being rearranged, "" when none is
+}
+
+// WithWidth sets the total width the columns are laid out in, separators
+// included. With 0, every column gets its natural width and Fit columns are
+// never truncated.
+func (t *Table) WithWidth(width int) *Table {
+	t.width = width
+	t.laidOut = nil
+	return t
+}
+
+// WithLayout rearranges the columns, for example to keep the arrangement of
+// a table that is being rebuilt.
+func (t *Table) WithLayout(layout Layout) *Table {
+	t.layout = Layout{
+		Order:  append([]string(nil), layout.Order...),
+		Hidden: map[string]bool{},
+		Widths: map[string]int{},
+		Focus:  layout.Focus,
+	}
+	t.laidOut = nil
+	for key, hidden := range layout.Hidden {
+		t.layout.Hidden[key] = hidden
+	}
+	for key, width := range layout.Widths {
+		t.layout.Widths[key] = width
+	}
+	return t
+}
+
+// LayoutState returns the current arrangement of the columns.
+func (t *Table) LayoutState() Layout {
+	return t.layout
+}
+
+// ordered returns all columns, hidden ones included, in display order.
+func (t *Table) ordered() []Column {
+	var columns []Column
+	for _, key := range t.layout.Order {
+		for _, col := range t.Columns {
+			if col.Key == key {
+				columns = append(columns, col)
+			}
+		}
+	}
+	for _, col := range t.Columns {
+		if indexOfKey(columns, col.Key) < 0 {
+			columns = append(columns, col)
+		}
+	}
+	return columns
+}
+
+// arranged returns the visible columns in display order, with the widths set
+// by resizing applied.
+func (t *Table) arranged() []Column {
+	var visible []Column
+	for _, col := range t.ordered() {
+		if t.layout.Hidden[col.Key] {
+			continue
+		}
+		if width, ok := t.layout.Widths[col.Key]; ok {
+			col.Width, col.MaxWidth = width, width
+			col.Fit, col.Flex, col.resized = false, 0, true
+			if col.MinWidth > width {
+				col.MinWidth = width // The user may go below the usual minimum
+			}
+		}
+		visible = append(visible, col)
+	}
+	return visible
+}
+
+// layoutColumns returns the visible columns in display order, with their
+// widths computed for the table width:
+//
+//  1. Each column starts at Width, or at the width of its content if Fit,
+//     kept between MinWidth and MaxWidth.
+//  2. Width left over is shared by the flexible columns by their Flex
+//     weight, up to their MaxWidth.
+//  3. If the columns are too wide, the flexible columns shrink by their
+//     weight, then the others from the right, down to MinWidth. Columns
+//     resized by the user shrink last.
+//
+// The result is kept until the rows, the width or the arrangement change, so
+// that moving the cursor does not measure every cell again. Callers must not
+// modify it.
+func (t *Table) layoutColumns() []Column {
+	if t.laidOut == nil {
+		t.laidOut = t.computeLayout()
+	}
+	return t.laidOut
+}
+
+// computeLayout lays out the columns as described at layoutColumns.
+func (t *Table) computeLayout() []Column {
+	columns := t.arranged()
+	if t.width <= 0 {
+		for i, col := range columns {
+			if col.Fit {
+				columns[i].Width = t.contentWidth(col, i == 0)
+			}
+		}
+		return columns
+	}
+
+	total := 0
+	for i, col := range columns {
+		if col.Fit {
+			col.Width = t.contentWidth(col, i == 0)
+		}
+		columns[i].Width = clampWidth(col.Width, col.MinWidth, col.MaxWidth)
+		total += columns[i].Width
+	}
+	available := t.width - (len(columns) - 1) // Separators
+
+	if total < available {
+		grow(columns, available-total)
+	} else if total > available {
+		shrink(columns, total-available)
+	}
+	return columns
+}
+
+// grow shares spare width among the flexible columns.
+func grow(columns []Column, spare int) {
+	for spare > 0 {
+		weights := 0
+		for _, col := range columns {
+			if col.Flex > 0 && (col.MaxWidth <= 0 || col.Width < col.MaxWidth) {
+				weights += col.Flex
+			}
+		}
+		if weights == 0 {
+			return
+		}
+		given := 0
+		for i, col := range columns {
+			if col.Flex <= 0 || col.MaxWidth > 0 && col.Width >= col.MaxWidth {
+				continue
+			}
+			share := spare * col.Flex / weights
+			if share == 0 {
+				share = 1 // Hand out the remainder
+			}
+			if share > spare-given {
+				share = spare - given
+			}
+			columns[i].Width = clampWidth(col.Width+share, col.MinWidth, col.MaxWidth)
+			given += columns[i].Width - col.Width
+		}
+		if given == 0 {
+			return
+		}
+		spare -= given
+	}
+}
+
+// shrink takes excess width from the flexible columns by weight, then from
+// the other columns starting at the right, and from resized columns last.
+func shrink(columns []Column, excess int) {
+	for excess > 0 {
+		weights := 0
+		for _, col := range columns {
+			if col.Flex > 0 && col.Width > minWidth(col) {
+				weights += col.Flex
+			}
+		}
+		if weights == 0 {
+			break
+		}
+		taken := 0
+		for i, col := range columns {
+			if col.Flex <= 0 || col.Width <= minWidth(col) {
+				continue
+			}
+			share := excess * col.Flex / weights
+			if share == 0 {
+				share = 1
+			}
+			if share > excess-taken {
+				share = excess - taken
+			}
+			if share > col.Width-minWidth(col) {
+				share = col.Width - minWidth(col)
+			}
+			columns[i].Width -= share
+			taken += share
+		}
+		if taken == 0 {
+			break
+		}
+		excess -= taken
+	}
+	for _, resized := range []bool{false, true} {
+		for i := len(columns) - 1; i >= 0 && excess > 0; i-- {
+			if columns[i].resized != resized {
+				continue
+			}
+			share := columns[i].Width - minWidth(columns[i])
+			if share > excess {
+				share = excess
+			}
+			if share > 0 {
+				columns[i].Width -= share
+				excess -= share
+			}
+		}
+	}
+}
+
+// minWidth returns the narrowest the layout makes col; no column shrinks
+// to nothing.
+func minWidth(col Column) int {
+	if col.MinWidth > 1 {
+		return col.MinWidth
+	}
+	return 1
+}
+
+// clampWidth keeps width between min and max, where 0 means no limit.
+func clampWidth(width, min, max int) int {
+	if max > 0 && width > max {
+		width = max
+	}
+	if width < min {
+		width = min
+	}
+	return width
+}
+
+// contentWidth returns the width of the title and longest cell of col. The
+// first column includes the indentation of nested rows.
+func (t *Table) contentWidth(col Column, first bool) int {
+	width := runewidth.StringWidth(col.Title)
+	for _, row := range t.Rows {
+		val, ok := row.Data[col.Key]
+		if !ok {
+			continue
+		}
+		cellWidth := runewidth.StringWidth(fmt.Sprintf("%v", val))
+		if first {
+			cellWidth += 2 * row.Depth
+		}
+		if cellWidth > width {
+			width = cellWidth
+		}
+	}
+	return width
+}
+
+// indexOfKey returns the index of the column with key in columns, or -1.
+func indexOfKey(columns []Column, key string) int {
+	for i, col := range columns {
+		if col.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+//------------------------------------------------------------------------------
+// Column Editing
+//------------------------------------------------------------------------------
+
+// resizeStep is how many characters < and > narrow or widen a column.
+const resizeStep = 2
+
+// focusedColumn returns the index of the focused column among the visible
+// ones. If no column is focused, it focuses the first one and returns -1, so
+// that a key pressed without a picked column only picks one and a stray x
+// does not hide Name.
+func (t *Table) focusedColumn(columns []Column) int {
+	if i := indexOfKey(columns, t.layout.Focus); i >= 0 {
+		return i
+	}
+	if len(columns) > 0 {
+		t.layout.Focus = columns[0].Key
+	}
+	return -1
+}
+
+// focusColumn moves the column focus by delta visible columns. Without a
+// focused column it focuses the first one.
+func (t *Table) focusColumn(delta int) {
+	columns := t.arranged()
+	i := t.focusedColumn(columns)
+	if i >= 0 && i+delta >= 0 && i+delta < len(columns) {
+		t.layout.Focus = columns[i+delta].Key
+	}
+}
+
+// moveColumn moves the focused column by delta places.
+func (t *Table) moveColumn(delta int) {
+	columns := t.arranged()
+	i := t.focusedColumn(columns)
+	if i < 0 || i+delta < 0 || i+delta >= len(columns) {
+		return
+	}
+	// Hidden columns keep their place relative to the visible ones
+	var order []string
+	for _, col := range t.ordered() {
+		order = append(order, col.Key)
+	}
+	from, to := indexOfString(order, columns[i].Key), indexOfString(order, columns[i+delta].Key)
+	order[from], order[to] = order[to], order[from]
+	t.layout.Order = order
+	t.laidOut = nil
+}
+
+// resizeColumn widens the focused column by delta characters, or narrows it
+// for a negative delta. The width then stays as set.
+func (t *Table) resizeColumn(delta int) {
+	columns := t.layoutColumns()
+	i := t.focusedColumn(columns)
+	if i < 0 {
+		return
+	}
+	width := columns[i].Width + delta
+	if width < 1 {
+		width = 1
+	}
+	if t.layout.Widths == nil {
+		t.layout.Widths = map[string]int{}
+	}
+	t.layout.Widths[columns[i].Key] = width
+	t.laidOut = nil
+}
+
+// hideColumn hides the focused column and focuses the next one. The last
+// visible column cannot be hidden.
+func (t *Table) hideColumn() {
+	columns := t.arranged()
+	i := t.focusedColumn(columns)
+	if i < 0 || len(columns) == 1 {
+		return
+	}
+	if t.layout.Hidden == nil {
+		t.layout.Hidden = map[string]bool{}
+	}
+	t.layout.Hidden[columns[i].Key] = true
+	t.laidOut = nil
+	if i+1 < len(columns) {
+		t.layout.Focus = columns[i+1].Key
+	} else {
+		t.layout.Focus = columns[i-1].Key
+	}
+}
+
+// resetLayout shows all columns again at their own widths and in their own
+// order.
+func (t *Table) resetLayout() {
+	t.layout = Layout{}
+	t.laidOut = nil
+}
+
+// indexOfString returns the index of value in list, or -1.
+func indexOfString(list []string, value string) int {
+	for i, v := range list {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,187 @@
+package table
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+// Sort Definitions
+//------------------------------------------------------------------------------
+
+// SortOption is a key the rows can be sorted by. It does not have to be one
+// of the table's columns.
+type SortOption struct {
+	Key   string // Row data key, passed to the sort value function
+	Title string // Name shown for the sort order
+}
+
+// Sort describes the order of the rows.
+type Sort struct {
+	Key        string // Key to sort by, "" for the order the rows were given in
+	Descending bool   // Whether the largest values come first
+	GroupFirst bool   // Whether rows in the table's group come first, e.g. directories
+}
+
+//------------------------------------------------------------------------------
+// Sorting
+//------------------------------------------------------------------------------
+
+// sortRows returns rows in the table's sort order. Nested rows stay below
+// the row they are nested under and are sorted among their siblings.
+func (t *Table) sortRows(rows []Row) []Row {
+	if t.sorting.Key == "" && !(t.sorting.GroupFirst && t.group != nil) {
+		return rows
+	}
+
+	// Group the rows by parent; rows whose parent is not listed, for example
+	// because a search filtered it out, are sorted with the top-level rows
+	ids := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		if row.ID != "" {
+			ids[row.ID] = true
+		}
+	}
+	children := make(map[string][]Row)
+	var roots []Row
+	for _, row := range rows {
+		if row.Parent != "" && ids[row.Parent] && row.Parent != row.ID {
+			children[row.Parent] = append(children[row.Parent], row)
+		} else {
+			roots = append(roots, row)
+		}
+	}
+
+	sorted := make([]Row, 0, len(rows))
+	var appendSorted func(siblings []Row)
+	appendSorted = func(siblings []Row) {
+		t.sortSiblings(siblings)
+		for _, row := range siblings {
+			sorted = append(sorted, row)
+			if row.ID != "" {
+				appendSorted(children[row.ID])
+				delete(children, row.ID) // Each ID's children are added once
+			}
+		}
+	}
+	appendSorted(roots)
+	return sorted
+}
+
+// sortSiblings sorts rows of the same level in place, keeping the given
+// order among equal rows. Sort values are looked up once per row, since
+// they may be costly, like the size of a file.
+func (t *Table) sortSiblings(rows []Row) {
+	type sortItem struct {
+		row   Row
+		value interface{}
+		group bool
+	}
+	items := make([]sortItem, len(rows))
+	for i, row := range rows {
+		items[i].row = row
+		if t.sorting.Key != "" {
+			items[i].value = t.valueOf(row)
+		}
+		if t.sorting.GroupFirst && t.group != nil {
+			items[i].group = t.group(row)
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].group != items[j].group {
+			return items[i].group
+		}
+		c := compareValues(items[i].value, items[j].value)
+		if t.sorting.Descending {
+			return c > 0
+		}
+		return c < 0
+	})
+	for i, item := range items {
+		rows[i] = item.row
+	}
+}
+
+// valueOf returns the value of row to sort by.
+func (t *Table) valueOf(row Row) interface{} {
+	if t.sortValue != nil {
+		return t.sortValue(row, t.sorting.Key)
+	}
+	return row.Data[t.sorting.Key]
+}
+
+// compareValues orders strings ignoring case, numbers and times by value,
+// and anything else by its text. nil comes before any value.
+func compareValues(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	switch a := a.(type) {
+	case string:
+		if b, ok := b.(string); ok {
+			if c := strings.Compare(strings.ToLower(a), strings.ToLower(b)); c != 0 {
+				return c
+			}
+			return strings.Compare(a, b)
+		}
+	case int:
+		if b, ok := b.(int); ok {
+			return compareInts(int64(a), int64(b))
+		}
+	case int64:
+		if b, ok := b.(int64); ok {
+			return compareInts(a, b)
+		}
+	case time.Time:
+		if b, ok := b.(time.Time); ok {
+			return a.Compare(b)
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// sortIndicator returns the arrow shown next to the title of the sort key.
+func (t *Table) sortIndicator() string {
+	if t.sorting.Descending {
+		return "▼"
+	}
+	return "▲"
+}
+
+// SortDescription describes the sort order for display, like "Size ▼", or
+// returns "" when the rows are in the order they were given in.
+func (t *Table) SortDescription() string {
+	var parts []string
+	if t.sorting.GroupFirst && t.group != nil {
+		parts = append(parts, t.groupTitle)
+	}
+	if t.sorting.Key != "" {
+		title := t.sorting.Key
+		for _, option := range t.sortOptions {
+			if option.Key == t.sorting.Key {
+				title = option.Title
+			}
+		}
+		parts = append(parts, title+" "+t.sortIndicator())
+	}
+	return strings.Join(parts, ", ")
+}
@@ -3,6 +3,7 @@ package table
 import (
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -17,8 +18,13 @@ import (
 type Column struct {
 	Key      string // Unique identifier for the column
 	Title    string // Display title for the column header
-	Width    int    // Width of the column in characters
+	Width    int    // Width of the column in characters, unless Fit
 	Filtered bool   // Whether this column should be included in filtering
+	MinWidth int    // Narrowest the column gets when the table fits a width, 0 for 1
+	MaxWidth int    // Widest the column gets when the table fits a width, 0 for no limit
+	Flex     int    // Share of the spare width the column gets, 0 for none
+	Fit      bool   // Whether the column is as wide as its title and longest cell
+	resized  bool   // Whether the user set Width; such columns shrink last
 }
 
 // NewColumn creates a new column.
@@ -41,7 +47,14 @@ type RowData map[string]interface{}
 
 // Row represents a single table row.
 type Row struct {
-	Data RowData // Map of column keys to cell values
+	ID     string  // Stable identifier of the row, independent of the displayed data
+	Data   RowData // Map of column keys to cell values
+	Depth  int     // Nesting level; the first column is indented by this much
+	Parent string  // ID of the row this one is nested under, "" at the top
+
+	// Rune offsets into the cell value of each column to render with the
+	// Match style, for example the characters a search matched
+	Highlights map[string][]int
 }
 
 // NewRow creates a new row from the given RowData.
@@ -49,6 +62,19 @@ func NewRow(data RowData) Row {
 	return Row{Data: data}
 }
 
+// WithID is a chainable method to set the row's identifier.
+func (r Row) WithID(id string) Row {
+	r.ID = id
+	return r
+}
+
+// WithParent is a chainable method to nest the row under parent at depth.
+func (r Row) WithParent(parent string, depth int) Row {
+	r.Parent = parent
+	r.Depth = depth
+	return r
+}
+
 //------------------------------------------------------------------------------
 // Styling
 //------------------------------------------------------------------------------
@@ -59,6 +85,23 @@ type Styles struct {
 	Selected lipgloss.Style // Style for the selected row
 	Normal   lipgloss.Style // Style for normal (unselected) rows
 	Border   lipgloss.Style // Style for table borders
+	Match    lipgloss.Style // Style for highlighted characters, on top of the row style
+	Marked   lipgloss.Style // Style for rows marked for a bulk action
+	Focused  lipgloss.Style // Style for the title of the column being rearranged
+}
+
+// PlainStyles returns styles without colors or emphasis, for output that is
+// not shown in an interactive terminal.
+func PlainStyles() Styles {
+	return Styles{
+		Border:   lipgloss.NewStyle(),
+		Header:   lipgloss.NewStyle(),
+		Selected: lipgloss.NewStyle(),
+		Normal:   lipgloss.NewStyle(),
+		Match:    lipgloss.NewStyle(),
+		Marked:   lipgloss.NewStyle(),
+		Focused:  lipgloss.NewStyle(),
+	}
 }
 
 // DefaultStyles returns a set of default styles for the table
@@ -76,6 +119,16 @@ func DefaultStyles() Styles {
 			Background(lipgloss.Color("90")),
 		Normal: lipgloss.NewStyle().
 			Foreground(lipgloss.Color("252")),
+		Match: lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("212")),
+		Marked: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("229")).
+			Background(lipgloss.Color("238")),
+		Focused: lipgloss.NewStyle().
+			Bold(true).
+			Underline(true).
+			Foreground(lipgloss.Color("212")),
 	}
 }
 
@@ -94,6 +147,20 @@ type Table struct {
 	styles   Styles        // Visual styles for the table
 	currentPage int        // Add this new field to track current page
 	lastKey string        // Add this field to track the last key pressed for "gg" command
+	hideHeader bool       // Whether the column titles and separator are left out
+	marked  map[string]bool // IDs of the rows marked for a bulk action
+	anchor  int             // Row where the visual range started, -1 outside visual mode
+
+	width   int      // Total width the columns are laid out in, 0 for natural widths
+	layout  Layout   // How the user rearranged the columns
+	laidOut []Column // Columns with their widths as last laid out, nil when out of date
+
+	unsorted    []Row                                // Rows in the order they were given
+	sortOptions []SortOption                         // Keys the s key cycles through
+	sorting     Sort                                 // Current sort order
+	sortValue   func(row Row, key string) interface{} // Value of row to sort by for key
+	group       func(row Row) bool                   // Rows put first when Sort.GroupFirst is set
+	groupTitle  string                               // Describes the group order, e.g. "directories first"
 }
 
 // New creates a new table instance with the provided columns.
@@ -107,6 +174,8 @@ func New(columns []Column) *Table {
 		filtered:   false,
 		currentPage: 0,    // Initialize current page
 		lastKey:    "",
+		marked:     map[string]bool{},
+		anchor:     -1,
 	}
 }
 
@@ -126,18 +195,62 @@ func (t *Table) WithFiltered(filtered bool) *Table {
 	return t
 }
 
+// WithHeader sets whether the column titles and separator line are shown.
+func (t *Table) WithHeader(show bool) *Table {
+	t.hideHeader = !show
+	return t
+}
+
 // WithFocused sets whether the table currently has focus.
 func (t *Table) WithFocused(focused bool) *Table {
 	t.focused = focused
 	return t
 }
 
-// WithRows sets the rows of the table.
+// WithRows sets the rows of the table, shown in the current sort order.
 func (t *Table) WithRows(rows []Row) *Table {
-	t.Rows = rows
+	t.unsorted = rows
+	t.Rows = t.sortRows(rows)
+	t.laidOut = nil
 	return t
 }
 
+// WithSortOptions sets the keys the s key cycles through.
+func (t *Table) WithSortOptions(options []SortOption) *Table {
+	t.sortOptions = options
+	return t
+}
+
+// WithSortValue sets how the value of a row for a sort key is found. By
+// default it is the row's data for that key.
+func (t *Table) WithSortValue(value func(row Row, key string) interface{}) *Table {
+	t.sortValue = value
+	t.Rows = t.sortRows(t.unsorted)
+	return t
+}
+
+// WithGroup sets which rows come first when Sort.GroupFirst is set, and
+// how that order is described.
+func (t *Table) WithGroup(title string, group func(row Row) bool) *Table {
+	t.groupTitle = title
+	t.group = group
+	t.Rows = t.sortRows(t.unsorted)
+	return t
+}
+
+// WithSort sets the sort order, for example to keep that of a table that is
+// being rebuilt.
+func (t *Table) WithSort(sorting Sort) *Table {
+	t.sorting = sorting
+	t.Rows = t.sortRows(t.unsorted)
+	return t
+}
+
+// SortState returns the current sort order.
+func (t *Table) SortState() Sort {
+	return t.sorting
+}
+
 // WithKeyMap is provided for chaining but not used in this implementation.
 func (t *Table) WithKeyMap(keyMap interface{}) *Table {
 	return t
@@ -179,20 +292,87 @@ func (t *Table) Update(msg tea.Msg) (*Table, tea.Cmd) {
 				}
 			}
 		case "down", "j":
-			if t.Selected < len(t.Rows)-1 {
-				t.Selected++
-				// Update page if selection moves below current page
-				if t.Selected >= (t.currentPage+1)*t.PageSize {
-					t.currentPage++
-				}
-			}
-		case "h": // Page up
+			t.moveDown()
+		case "pgup", "ctrl+b": // Page up
 			t.currentPage = max(0, t.currentPage-1)
 			t.Selected = max(0, t.currentPage*t.PageSize)
-		case "l": // Page down
+		case "pgdown", "ctrl+f": // Page down
 			maxPage := (len(t.Rows) - 1) / t.PageSize
 			t.currentPage = min(maxPage, t.currentPage+1)
 			t.Selected = min(len(t.Rows)-1, (t.currentPage+1)*t.PageSize-1)
+		case " ": // Mark or unmark the row and move on
+			if len(t.Rows) > 0 {
+				id := t.Rows[t.Selected].ID
+				t.marked[id] = !t.marked[id]
+				if !t.marked[id] {
+					delete(t.marked, id)
+				}
+				t.moveDown()
+			}
+		case "V": // Start a visual range, or mark it and stop
+			if t.anchor < 0 {
+				t.anchor = t.Selected
+			} else {
+				for _, row := range t.visualRange() {
+					t.marked[row.ID] = true
+				}
+				t.anchor = -1
+			}
+		case "*": // Mark every row
+			for _, row := range t.Rows {
+				t.marked[row.ID] = true
+			}
+		case "esc": // Stop picking columns, or else clear marks and the visual range
+			if t.layout.Focus != "" {
+				t.layout.Focus = ""
+			} else {
+				t.ClearMarks()
+			}
+		case "[", "]": // Focus the previous or next column
+			if key == "[" {
+				t.focusColumn(-1)
+			} else {
+				t.focusColumn(1)
+			}
+		case "{", "}": // Move the focused column left or right
+			if key == "{" {
+				t.moveColumn(-1)
+			} else {
+				t.moveColumn(1)
+			}
+		case "<", ">": // Narrow or widen the focused column
+			if key == "<" {
+				t.resizeColumn(-resizeStep)
+			} else {
+				t.resizeColumn(resizeStep)
+			}
+		case "x": // Hide the focused column
+			t.hideColumn()
+		case "X": // Show all columns as they were
+			t.resetLayout()
+		case "s": // Sort by the next sort option
+			next := Sort{Key: "", GroupFirst: t.sorting.GroupFirst}
+			for i, option := range t.sortOptions {
+				if option.Key == t.sorting.Key && i+1 < len(t.sortOptions) {
+					next.Key = t.sortOptions[i+1].Key
+				}
+			}
+			if t.sorting.Key == "" && len(t.sortOptions) > 0 {
+				next.Key = t.sortOptions[0].Key
+			}
+			t.resort(next)
+		case "S": // Reverse the sort direction
+			if t.sorting.Key != "" {
+				next := t.sorting
+				next.Descending = !next.Descending
+				t.resort(next)
+			}
+		case "D": // Toggle putting the group, e.g. directories, first
+			if t.group != nil {
+				next := t.sorting
+				next.GroupFirst = !next.GroupFirst
+				t.resort(next)
+			}
 		case "G": // Jump to bottom
 			t.Selected = len(t.Rows) - 1
 			t.currentPage = (t.Selected) / t.PageSize
@@ -211,12 +391,119 @@ func (t *Table) Update(msg tea.Msg) (*Table, tea.Cmd) {
 	return t, nil
 }
 
-// SelectedRows returns the currently selected row(s) as a slice.
+// resort applies a new sort order and keeps the cursor on the same row.
+func (t *Table) resort(sorting Sort) {
+	cursor, ok := t.CursorRow()
+	t.WithSort(sorting)
+	if ok {
+		t.SelectID(cursor.ID)
+	}
+}
+
+// moveDown moves the selection to the next row, if any.
+func (t *Table) moveDown() {
+	if t.Selected < len(t.Rows)-1 {
+		t.Selected++
+		// Update page if selection moves below current page
+		if t.Selected >= (t.currentPage+1)*t.PageSize {
+			t.currentPage++
+		}
+	}
+}
+
+// SetPage shows the given page without moving the selection.
+func (t *Table) SetPage(page int) *Table {
+	t.currentPage = page
+	return t
+}
+
+// SelectID selects the row with the given ID and shows its page. It reports
+// whether such a row exists.
+func (t *Table) SelectID(id string) bool {
+	for i, row := range t.Rows {
+		if row.ID == id {
+			t.Selected = i
+			t.currentPage = i / t.PageSize
+			return true
+		}
+	}
+	return false
+}
+
+// SelectedRows returns the rows an action applies to: the marked rows and
+// the visual range, in table order, or else the row under the cursor. Marked
+// rows that are currently filtered out are left out, so when no marked row
+// is shown the action applies to the row under the cursor.
 func (t *Table) SelectedRows() []Row {
 	if len(t.Rows) == 0 {
 		return []Row{}
 	}
-	return []Row{t.Rows[t.Selected]}
+	var rows []Row
+	for i, row := range t.Rows {
+		if t.isMarked(i) {
+			rows = append(rows, row)
+		}
+	}
+	if len(rows) == 0 {
+		return []Row{t.Rows[t.Selected]}
+	}
+	return rows
+}
+
+// CursorRow returns the row under the cursor, ignoring marks.
+func (t *Table) CursorRow() (Row, bool) {
+	if len(t.Rows) == 0 {
+		return Row{}, false
+	}
+	return t.Rows[t.Selected], true
+}
+
+// MarkedIDs returns the IDs of the marked rows.
+func (t *Table) MarkedIDs() []string {
+	ids := make([]string, 0, len(t.marked))
+	for id := range t.marked {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// WithMarked marks the rows with the given IDs, for example to keep the marks
+// of a table that is being rebuilt.
+func (t *Table) WithMarked(ids []string) *Table {
+	for _, id := range ids {
+		t.marked[id] = true
+	}
+	return t
+}
+
+// ClearMarks unmarks all rows and leaves visual mode.
+func (t *Table) ClearMarks() *Table {
+	t.marked = map[string]bool{}
+	t.anchor = -1
+	return t
+}
+
+// visualRange returns the rows between the visual anchor and the cursor.
+func (t *Table) visualRange() []Row {
+	if t.anchor < 0 || t.anchor >= len(t.Rows) {
+		return nil
+	}
+	from, to := t.anchor, t.Selected
+	if from > to {
+		from, to = to, from
+	}
+	return t.Rows[from : to+1]
+}
+
+// isMarked reports whether the row at index i is marked or in the visual range.
+func (t *Table) isMarked(i int) bool {
+	if t.marked[t.Rows[i].ID] {
+		return true
+	}
+	if t.anchor < 0 || t.anchor >= len(t.Rows) {
+		return false
+	}
+	return (t.anchor <= i && i <= t.Selected) || (t.Selected <= i && i <= t.anchor)
 }
 
 //------------------------------------------------------------------------------
@@ -227,32 +514,10 @@ func (t *Table) SelectedRows() []Row {
 func (t *Table) View() string {
 	var b strings.Builder
 	
-	// Create the header with proper width and alignment
-	headerRow := ""
-	for i, col := range t.Columns {
-		if i > 0 {
-			headerRow += "│" // Add column separator
-		}
-		// Adjust width consistently with other rows
-		title := fmt.Sprintf("%-*s", col.Width, col.Title)
-		if len(title) > col.Width {
-			title = title[:col.Width-3] + "..." // Truncate with ellipsis if too long
-		}
-		headerRow += title
+	columns := t.layoutColumns()
+	if !t.hideHeader {
+		t.writeHeader(&b, columns)
 	}
-	b.WriteString(t.styles.Header.Render(headerRow))
-	
-	// Add separator line with intersections
-	b.WriteString("\n")
-	separatorLine := ""
-	for i := 0; i < len(t.Columns); i++ {
-		if i > 0 {
-			separatorLine += "┼" + strings.Repeat("─", t.Columns[i].Width) // Add intersection and horizontal line
-		} else {
-			separatorLine += strings.Repeat("─", t.Columns[i].Width) // Just horizontal line for first column
-		}
-	}
-	b.WriteString(separatorLine)
 	
 	// Calculate visible rows for current page
 	startIdx := t.currentPage * t.PageSize
@@ -261,20 +526,29 @@ func (t *Table) View() string {
 	
 	// Render rows
 	for i, row := range visibleRows {
-		b.WriteString("\n")
+		if i > 0 || !t.hideHeader {
+			b.WriteString("\n")
+		}
 		rowContent := ""
-		for j, col := range t.Columns {
+		var marks map[int]bool // Rune offsets into rowContent to highlight
+		for j, col := range columns {
 			if j > 0 {
 				rowContent += "│" // Add column separator
 			}
 			cell := ""
 			if val, ok := row.Data[col.Key]; ok {
 				cell = fmt.Sprintf("%v", val)
+				indent := 0
+				if j == 0 {
+					indent = 2 * row.Depth
+					cell = strings.Repeat(" ", indent) + cell // Indent nested rows
+				}
 				
 				// Calculate visual width accounting for special characters
 				visualWidth := runewidth.StringWidth(cell)
 				
 				// Truncate if needed based on visual width
+				kept := utf8.RuneCountInString(cell) // Runes of the value still shown
 				if visualWidth > col.Width {
 					if col.Width > 3 {
 						// Truncate carefully considering visual width
@@ -289,8 +563,10 @@ func (t *Table) View() string {
 							currentWidth += charWidth
 						}
 						cell = truncated + "..." // Add ellipsis to indicate truncation
+						kept = utf8.RuneCountInString(truncated)
 					} else {
 						cell = strings.Repeat(".", col.Width) // For very narrow columns, just use dots
+						kept = 0
 					}
 				}
 				
@@ -299,6 +575,19 @@ func (t *Table) View() string {
 				if padding > 0 {
 					cell = cell + strings.Repeat(" ", padding) // Right-pad with spaces
 				}
+
+				// Highlight the characters that survived truncation
+				if offsets := row.Highlights[col.Key]; len(offsets) > 0 {
+					if marks == nil {
+						marks = map[int]bool{}
+					}
+					start := utf8.RuneCountInString(rowContent)
+					for _, offset := range offsets {
+						if offset+indent < kept {
+							marks[start+offset+indent] = true
+						}
+					}
+				}
 			} else {
 				// Empty cell with proper padding
 				cell = strings.Repeat(" ", col.Width)
@@ -307,10 +596,16 @@ func (t *Table) View() string {
 			rowContent += cell
 		}
 		// Adjust the selection highlighting to account for paging
+		style := t.styles.Normal
 		if t.focused && (startIdx+i) == t.Selected {
-			rowContent = t.styles.Selected.Render(rowContent)
+			style = t.styles.Selected
+		} else if t.isMarked(startIdx + i) {
+			style = t.styles.Marked
+		}
+		if len(marks) == 0 {
+			rowContent = style.Render(rowContent)
 		} else {
-			rowContent = t.styles.Normal.Render(rowContent)
+			rowContent = t.renderMarked(rowContent, marks, style)
 		}
 		
 		b.WriteString(rowContent)
@@ -325,6 +620,71 @@ func (t *Table) View() string {
 	return b.String()
 }
 
+// renderMarked renders text in style, except for the runes at the offsets in
+// marks, which get the Match style on top.
+func (t *Table) renderMarked(text string, marks map[int]bool, style lipgloss.Style) string {
+	matchStyle := t.styles.Match.Inherit(style)
+	var b strings.Builder
+	var run []rune
+	runMarked := false
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		if runMarked {
+			b.WriteString(matchStyle.Render(string(run)))
+		} else {
+			b.WriteString(style.Render(string(run)))
+		}
+		run = run[:0]
+	}
+	for i, r := range []rune(text) {
+		if marks[i] != runMarked {
+			flush()
+			runMarked = marks[i]
+		}
+		run = append(run, r)
+	}
+	flush()
+	return b.String()
+}
+
+// writeHeader renders the column titles and the separator line below them.
+func (t *Table) writeHeader(b *strings.Builder, columns []Column) {
+	// Create the header with proper width and alignment
+	headerRow := ""
+	for i, col := range columns {
+		if i > 0 {
+			headerRow += t.styles.Header.Render("│") // Add column separator
+		}
+		title := col.Title
+		if col.Key == t.sorting.Key {
+			title += " " + t.sortIndicator()
+		}
+		// Adjust width consistently with other rows
+		title = runewidth.Truncate(title, col.Width, "...") // Truncate with ellipsis if too long
+		title = runewidth.FillRight(title, col.Width)
+		if col.Key == t.layout.Focus {
+			headerRow += t.styles.Focused.Render(title)
+		} else {
+			headerRow += t.styles.Header.Render(title)
+		}
+	}
+	b.WriteString(headerRow)
+	
+	// Add separator line with intersections
+	b.WriteString("\n")
+	separatorLine := ""
+	for i := 0; i < len(columns); i++ {
+		if i > 0 {
+			separatorLine += "┼" + strings.Repeat("─", columns[i].Width) // Add intersection and horizontal line
+		} else {
+			separatorLine += strings.Repeat("─", columns[i].Width) // Just horizontal line for first column
+		}
+	}
+	b.WriteString(separatorLine)
+}
+
 // Helper functions
 func min(a, b int) int {
 	if a < b {
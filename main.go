@@ -1,48 +1,252 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
+	"strings"
 
+	"lanno/internal/config"
 	"lanno/internal/file_stat"
+	"lanno/internal/store"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/term"
 )
 
 const helpText = `lanno - A file tagging and organization tool
 
 Usage:
     lanno                    # Launch interactive file browser
+    lanno --columns <list>   # Browse with optional columns, see Columns
     lanno <file> <command>   # Tag or describe a file
+    lanno --force <file> <command>  # Annotate a file that does not exist yet
+    lanno ls [dir]           # Print the listing as plain text
+    lanno find <query>       # Search annotations below the current directory
+    lanno prune [dir]        # List annotations of deleted files
+    lanno mv <old> <new>     # Move a file together with its annotation
 
 Commands:
     +<tag>                   # Add a tag to a file
     -<tag>                   # Remove a tag from a file
     <description>            # Set description for a file
 
+Subcommands:
+    ls [--recursive] [--tag <tag>] [--columns <list>] [--no-header]
+       [--format <format>] [dir]
+                             # Print the Name/Tags/Description table without
+                             # the interactive browser; lanno does this by
+                             # itself when stdout is not a terminal.
+                             # --format json|ndjson|csv|tsv prints paths, tags,
+                             # descriptions, is_dir and file timestamps
+    find [--dir <dir>] [--tag <tag>] [--desc <regexp>] [--name <glob>]
+         [-L] [-x] [--format <format>] [query]
+                             # Print the paths of all files below dir whose
+                             # annotations match query (see Queries) and the
+                             # given filters; -L follows symlinked directories,
+                             # -x stays on one file system
+    prune [--yes] [--dry-run] [dir]
+                             # Find annotations whose files are gone; --yes
+                             # removes them, --dry-run prints a JSON report
+    mv <source> <target>     # Rename or move a file and carry its annotation
+                             # along, also into another directory
+    cp <source> <target>     # Copy a file and duplicate its annotation
+    reconcile [--yes] [--recursive] [dir]
+                             # Match annotations of vanished files to new
+                             # files with the same content; --yes re-keys them
+
+A file that shares its name with a subcommand can be annotated as ./<name>.
+
 Examples:
     lanno document.txt +work     # Add #work tag to document.txt
     lanno document.txt -work     # Remove #work tag from document.txt
     lanno document.txt "Important work document"  # Set description
     lanno document.txt +urgent "Important work document"  # Add tag and description
 
+Exit Status:
+    0              # Success
+    1              # Any other failure
+    2              # Bad command syntax
+    3              # File not found (see --force)
+    4              # .lanno.json is corrupt and was left untouched
+
+Columns:
+    size, mode, owner, group, target, entries
+                   # Human-readable size, permissions, owning user and
+                   # group, symlink target and number of entries of a
+                   # directory, like ls -l shows them. Pick them with a
+                   # comma-separated --columns list, or by default with
+                   # "columns" in config.json in the lanno directory of
+                   # the user configuration directory, e.g.
+                   # ~/.config/lanno/config.json: {"columns": ["size"]}
+
+Queries:
+    word           # Name, tags or description contain word
+    tag:backend    # Tagged #backend (tag:back* matches by glob)
+    desc:"entry point"       # Description contains "entry point"
+    name:*.go      # Name matches a glob, or contains a plain word
+    a AND b, a OR b, NOT a, ( ... )
+                   # Adjacent terms are joined with AND
+    Used by lanno find and by the exact search of the interactive browser.
+
 Interactive Mode:
-    /              # Search files
-    ctrl+e         # Edit selected file tags or description, +<tag>, -<tag>, or <description>
+    enter or l     # Open the selected directory
+    backspace or h # Go to the parent directory
+    pgup/pgdown    # Previous/next page (also ctrl+b/ctrl+f)
+    t              # Toggle tree mode; enter expands or collapses a
+                   # directory in place, l expands, h collapses
+    /              # Search files; tab switches between fuzzy matching,
+                   # exact (see Queries) and regular expressions. Enter
+                   # keeps the filter, esc clears it
+    T              # Show the modification time, then also the creation
+                   # and access times, then hide them again
+    s              # Sort by the next column: name, tags, description,
+                   # size, modified, created, accessed time, or none
+    S              # Reverse the sort order
+    D              # Toggle listing directories first
+    space          # Mark or unmark the selected file
+    V              # Start a range; V again marks every file in it
+    *              # Mark every file shown, e.g. all search results
+    esc            # Stop picking columns, or else clear the marks
+    [ ]            # Pick the previous or next column
+    { }            # Move the picked column left or right
+    < >            # Narrow or widen the picked column
+    x              # Hide the picked column
+    X              # Show all columns in their own order and width
+    ctrl+e         # Edit selected file tags or description, +<tag>, -<tag>, or <description>;
+                   # with files marked, the command applies to all of them
+                   # Files marked ❌ are annotated but no longer exist
     q or ctrl+c    # Quit
 `
 
 // Version represents the current application version
 const Version = "1.2.0"
 
+// Exit codes reported for failed commands
+const (
+	exitFailure  = 1
+	exitSyntax   = 2
+	exitNotFound = 3
+	exitCorrupt  = 4
+)
+
+// exitCode maps an error to the exit code documented in the help text.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, file_stat.ErrSyntax):
+		return exitSyntax
+	case errors.Is(err, fs.ErrNotExist):
+		return exitNotFound
+	case errors.Is(err, store.ErrCorrupt):
+		return exitCorrupt
+	default:
+		return exitFailure
+	}
+}
+
+// fail prints err to stderr and exits with the matching exit code.
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "lanno: %v\n", err)
+	os.Exit(exitCode(err))
+}
+
 func printHelp() {
 	fmt.Print(helpText)
 	os.Exit(0)
 }
 
-func view() {
-	p := tea.NewProgram(file_stat.NewModel())
+// leadingOptions are the options accepted before the file argument, with the
+// number of arguments each takes up, its value included.
+var leadingOptions = map[string]int{"--force": 1, "--columns": 2}
+
+// optionLength returns how many arguments the option at the start of args
+// takes up, or 0 if args does not start with an option.
+func optionLength(args []string) int {
+	for option, length := range leadingOptions {
+		if args[0] == option {
+			if length > len(args) {
+				return len(args)
+			}
+			return length
+		}
+		if length > 1 && strings.HasPrefix(args[0], option+"=") {
+			return 1
+		}
+	}
+	return 0
+}
+
+// extractFlag removes flag from the options in front of the file argument
+// and reports whether it was present. Scanning stops at the first argument
+// that is not an option, or at "--", so a description may contain the flag.
+// Tag commands look like flags themselves ("-tag"), so the flag package
+// cannot be used for the tag command line.
+func extractFlag(args []string, flag string) (bool, []string) {
+	found := false
+	rest := make([]string, 0, len(args))
+	i := 0
+	for i < len(args) && args[i] != "--" {
+		length := optionLength(args[i:])
+		if length == 0 {
+			break
+		}
+		if args[i] == flag {
+			found = true
+		} else {
+			rest = append(rest, args[i:i+length]...)
+		}
+		i += length
+	}
+	return found, append(rest, args[i:]...)
+}
+
+// extractOption removes the option flag and its value, given as the next
+// argument or as flag=value, from the options in front of the file argument,
+// like extractFlag. It reports whether the option was present; when it is
+// given more than once, the last value counts.
+func extractOption(args []string, flag string) (string, bool, []string) {
+	value, found := "", false
+	rest := make([]string, 0, len(args))
+	i := 0
+	for i < len(args) && args[i] != "--" {
+		length := optionLength(args[i:])
+		switch {
+		case length == 0:
+			return value, found, append(rest, args[i:]...)
+		case args[i] == flag:
+			if length < 2 {
+				fail(fmt.Errorf("%w: %s needs a value", file_stat.ErrSyntax, flag))
+			}
+			value, found = args[i+1], true
+		case strings.HasPrefix(args[i], flag+"="):
+			value, found = strings.TrimPrefix(args[i], flag+"="), true
+		default:
+			rest = append(rest, args[i:i+length]...)
+		}
+		i += length
+	}
+	return value, found, append(rest, args[i:]...)
+}
+
+// configColumns returns the optional columns chosen in the configuration
+// file.
+func configColumns() []string {
+	cfg, err := config.Load()
+	if err != nil {
+		fail(err)
+	}
+	columns, err := file_stat.CheckColumns(cfg.Columns)
+	if err != nil {
+		path, _ := config.Path()
+		fail(fmt.Errorf("%s: %w", path, err))
+	}
+	return columns
+}
+
+func view(columns []string) {
+	p := tea.NewProgram(file_stat.NewModel().WithColumns(columns))
 	if _, err := p.Run(); err != nil {
 		log.Fatal(err)
 	}
@@ -63,12 +267,43 @@ func main() {
 		}
 	}
 
+	// Dispatch subcommands
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			command(os.Args[2:])
+			return
+		}
+	}
+
 	// parse parameters
-	if len(os.Args) < 2 {
-		view()
+	force, args := extractFlag(os.Args[1:], "--force")
+	columns, hasColumns, args := extractOption(args, "--columns")
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:] // The file argument may look like an option
+	}
+	if len(args) < 1 && !term.IsTerminal(int(os.Stdout.Fd())) {
+		// Output goes to a pipe or file, so print a listing instead
+		if hasColumns {
+			listCommand([]string{"--columns", columns})
+		} else {
+			listCommand(nil)
+		}
+	} else if len(args) < 1 {
+		var keys []string
+		if hasColumns {
+			var err error
+			if keys, err = file_stat.ParseColumns(columns); err != nil {
+				fail(err)
+			}
+		} else {
+			keys = configColumns()
+		}
+		view(keys)
 	} else {
-		filePath := os.Args[1]
-		tagEditCommand := os.Args[2:]
-		file_stat.TagCommand(tagEditCommand, filePath)
+		filePath := args[0]
+		tagEditCommand := args[1:]
+		if err := file_stat.TagCommand(tagEditCommand, filePath, force); err != nil {
+			fail(err)
+		}
 	}
 }
@@ -0,0 +1,259 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"lanno/internal/file_stat"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sendKeys feeds keys to the model, running the command each key returns.
+// Runes of a string are sent one at a time, like typing.
+func sendKeys(m tea.Model, keys ...interface{}) tea.Model {
+	send := func(msg tea.Msg) {
+		var cmd tea.Cmd
+		m, cmd = m.Update(msg)
+		if cmd != nil {
+			if next := cmd(); next != nil {
+				m, _ = m.Update(next)
+			}
+		}
+	}
+	for _, k := range keys {
+		switch k := k.(type) {
+		case tea.KeyType:
+			send(tea.KeyMsg{Type: k})
+		case string:
+			for _, r := range k {
+				send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+			}
+		}
+	}
+	return m
+}
+
+// TestEditTruncatedName annotates a file whose name has spaces and is too
+// long for the Name column under its real name.
+func TestEditTruncatedName(t *testing.T) {
+	dir := t.TempDir()
+	name := "a rather long file name with spaces in it.txt"
+	os.WriteFile(filepath.Join(dir, name), nil, 0644)
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(dir)
+
+	var m tea.Model = file_stat.NewModel()
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 60, Height: 20})
+	if strings.Contains(m.View(), name) {
+		t.Fatalf("name is not truncated at this width:\n%s", m.View())
+	}
+	sendKeys(m, tea.KeyCtrlE, "+picked", tea.KeyEnter)
+
+	infos, err := file_stat.GetInfoFromAnnoFile(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, ok := infos[name]; !ok || len(info.Tags) != 1 || info.Tags[0] != "#picked" {
+		t.Fatalf("annotations = %+v, want #picked on %q", infos, name)
+	}
+}
+
+// TestBulkEdit tags the marked files with one command and leaves the
+// others alone.
+func TestBulkEdit(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.sql", "b.sql", "c.sql", "d.sql"} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(dir)
+
+	tagged := func() []string {
+		infos, _ := file_stat.GetInfoFromAnnoFile(dir)
+		var names []string
+		for _, name := range []string{"a.sql", "b.sql", "c.sql", "d.sql"} {
+			if len(infos[name].Tags) > 0 {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+
+	var m tea.Model = file_stat.NewModel()
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+
+	// Mark a.sql, skip b.sql, then mark c.sql and d.sql as a visual range
+	m = sendKeys(m, " ", "j", "V", "j", "V", tea.KeyCtrlE, "+migration", tea.KeyEnter)
+	if got := strings.Join(tagged(), " "); got != "a.sql c.sql d.sql" {
+		t.Fatalf("tagged %q, want a.sql, c.sql and d.sql", got)
+	}
+
+	// The marks are gone after the edit; * marks every row
+	m = sendKeys(m, "*", tea.KeyCtrlE, "-migration", tea.KeyEnter)
+	if got := tagged(); len(got) != 0 {
+		t.Fatalf("still tagged: %q", got)
+	}
+}
+
+// TestBulkEditMarksOutOfView edits the row under the cursor when the marked
+// files are not shown, and drops marks when changing directory.
+func TestBulkEditMarksOutOfView(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), nil, 0644)
+	os.WriteFile(filepath.Join(dir, "b.txt"), nil, 0644)
+	os.MkdirAll(filepath.Join(dir, "sub"), 0755)
+	os.WriteFile(filepath.Join(dir, "sub", "c.txt"), nil, 0644)
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(dir)
+
+	var m tea.Model = file_stat.NewModel()
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+
+	// Mark a.txt, then filter it out of view: the edit goes to b.txt
+	m = sendKeys(m, " ", "/", "b.txt", tea.KeyEnter, tea.KeyCtrlE, "+seen", tea.KeyEnter)
+	infos, _ := file_stat.GetInfoFromAnnoFile(dir)
+	if len(infos["a.txt"].Tags) != 0 || len(infos["b.txt"].Tags) != 1 {
+		t.Fatalf("annotations = %+v, want only b.txt tagged", infos)
+	}
+
+	// Mark a.txt, which moves the cursor to b.txt, then open sub: the edit
+	// goes to c.txt
+	m = sendKeys(m, tea.KeyEsc, "gg", " ", "j", "l")
+	if !strings.Contains(m.View(), "c.txt") {
+		t.Fatalf("sub is not open:\n%s", m.View())
+	}
+	sendKeys(m, tea.KeyCtrlE, "+nested", tea.KeyEnter)
+	infos, _ = file_stat.GetInfoFromAnnoFile(dir)
+	sub, _ := file_stat.GetInfoFromAnnoFile(filepath.Join(dir, "sub"))
+	if len(infos["a.txt"].Tags) != 0 || len(sub["c.txt"].Tags) != 1 {
+		t.Fatalf("annotations = %+v and %+v, want only sub/c.txt tagged", infos, sub)
+	}
+}
+
+// TestTimeColumns shows modification times with T and sorts by them.
+func TestTimeColumns(t *testing.T) {
+	dir := t.TempDir()
+	older := time.Date(2023, 5, 6, 7, 8, 0, 0, time.Local)
+	newer := older.Add(24 * time.Hour)
+	for name, mtime := range map[string]time.Time{"a.txt": newer, "b.txt": older} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, nil, 0644)
+		os.Chtimes(path, mtime, mtime)
+	}
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(dir)
+
+	var m tea.Model = file_stat.NewModel()
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	if strings.Contains(m.View(), "Modified") {
+		t.Fatalf("timestamps shown before pressing T:\n%s", m.View())
+	}
+
+	m = sendKeys(m, "T")
+	view := m.View()
+	if !strings.Contains(view, "Modified") || !strings.Contains(view, "2023-05-06 07:08") {
+		t.Fatalf("after T:\n%s\nwant a Modified column with 2023-05-06 07:08", view)
+	}
+
+	// Name, tags, description, size, then modification time
+	m = sendKeys(m, "sssss")
+	view = m.View()
+	if !strings.Contains(view, "Modified ▲") || strings.Index(view, "b.txt") > strings.Index(view, "a.txt") {
+		t.Fatalf("sorted by modification time:\n%s\nwant b.txt first", view)
+	}
+}
+
+// TestChangeDirectory opens a subdirectory with its own annotations and goes
+// back up with the subdirectory still selected.
+func TestChangeDirectory(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), nil, 0644)
+	os.MkdirAll(filepath.Join(dir, "sub"), 0755)
+	os.WriteFile(filepath.Join(dir, "sub", "c.txt"), nil, 0644)
+	file_stat.TagCommand([]string{"+nested"}, filepath.Join(dir, "sub", "c.txt"), false)
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(dir)
+	abs, _ := filepath.EvalSymlinks(dir)
+
+	var m tea.Model = file_stat.NewModel()
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+
+	// a.txt comes first, then sub
+	m = sendKeys(m, "j", tea.KeyEnter)
+	view := m.View()
+	if !strings.Contains(view, "📂 "+filepath.Join(abs, "sub")+"\n") {
+		t.Fatalf("breadcrumb does not show sub:\n%s", view)
+	}
+	if !strings.Contains(view, "c.txt") || !strings.Contains(view, "#nested") || strings.Contains(view, "a.txt") {
+		t.Fatalf("sub/.lanno.json not shown:\n%s", view)
+	}
+
+	m = sendKeys(m, tea.KeyBackspace)
+	if view := m.View(); !strings.Contains(view, "📂 "+abs+"\n") || !strings.Contains(view, "a.txt") {
+		t.Fatalf("not back in the parent directory:\n%s", view)
+	}
+	// The edit goes to the selected row, which must be sub
+	sendKeys(m, tea.KeyCtrlE, "+back", tea.KeyEnter)
+	infos, _ := file_stat.GetInfoFromAnnoFile(dir)
+	if len(infos["sub"].Tags) != 1 || len(infos["a.txt"].Tags) != 0 {
+		t.Fatalf("annotations = %+v, want sub selected after going back", infos)
+	}
+}
+
+// TestTreeMode expands a directory in place with its rows indented, keeps it
+// expanded on refresh and collapses it again from a nested row.
+func TestTreeMode(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), nil, 0644)
+	os.MkdirAll(filepath.Join(dir, "sub", "deep"), 0755)
+	os.WriteFile(filepath.Join(dir, "sub", "c.txt"), nil, 0644)
+	file_stat.TagCommand([]string{"+nested"}, filepath.Join(dir, "sub", "c.txt"), false)
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(dir)
+
+	var m tea.Model = file_stat.NewModel()
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+
+	expanded := func(view string) bool {
+		return strings.Contains(view, "│  📄 c.txt│#nested") && strings.Contains(view, "│  📁 deep")
+	}
+	for _, key := range []interface{}{tea.KeyEnter, "l"} {
+		m = sendKeys(m, "gg", "t", "j", key)
+		if view := m.View(); !expanded(view) || !strings.Contains(view, "a.txt") {
+			t.Fatalf("after t and %v, sub is not expanded in place:\n%s", key, view)
+		}
+
+		m = sendKeys(m, "r")
+		if view := m.View(); !expanded(view) {
+			t.Fatalf("refresh collapsed sub:\n%s", view)
+		}
+
+		// h on c.txt collapses sub and selects it
+		m = sendKeys(m, "j", "h")
+		if view := m.View(); strings.Contains(view, "c.txt") {
+			t.Fatalf("h on a nested row left sub expanded:\n%s", view)
+		}
+		m = sendKeys(m, "t")
+	}
+
+	m = sendKeys(m, "t", "j", "l", "j", "h", tea.KeyCtrlE, "+top", tea.KeyEnter)
+	if infos, _ := file_stat.GetInfoFromAnnoFile(dir); len(infos["sub"].Tags) != 1 {
+		t.Fatalf("annotations = %+v, want sub selected after collapsing", infos)
+	}
+}
@@ -0,0 +1,51 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	"lanno/internal/fuzzy"
+)
+
+// TestFuzzyMatch finds the characters of a pattern in order.
+func TestFuzzyMatch(t *testing.T) {
+	_, positions, ok := fuzzy.Match("mnhdlr", "main_handler.go")
+	if !ok {
+		t.Fatalf(`Match("mnhdlr", "main_handler.go") found nothing`)
+	}
+	if want := []int{0, 3, 5, 8, 9, 11}; !reflect.DeepEqual(positions, want) {
+		t.Fatalf("positions = %v, want %v", positions, want)
+	}
+
+	if _, _, ok := fuzzy.Match("rdl", "main_handler.go"); ok {
+		t.Errorf(`Match("rdl", "main_handler.go") matched out of order`)
+	}
+	if _, _, ok := fuzzy.Match("MH", "main_handler.go"); ok {
+		t.Errorf(`Match("MH", "main_handler.go") ignored case for an upper-case pattern`)
+	}
+	if _, _, ok := fuzzy.Match("MH", "Main_Handler.go"); !ok {
+		t.Errorf(`Match("MH", "Main_Handler.go") found nothing`)
+	}
+}
+
+// TestFuzzyMatchRanking scores matches at word boundaries and consecutive
+// characters above scattered ones.
+func TestFuzzyMatchRanking(t *testing.T) {
+	tests := []struct{ pattern, better, worse string }{
+		{"hdl", "main_handler.go", "ahead_of_line.go"},
+		{"main", "main.go", "domain_info.go"},
+		{"mh", "main_handler.go", "mishmash.txt"},
+		{"fb", "FooBar.go", "fabric.go"},
+	}
+	for _, tt := range tests {
+		better, _, ok1 := fuzzy.Match(tt.pattern, tt.better)
+		worse, _, ok2 := fuzzy.Match(tt.pattern, tt.worse)
+		if !ok1 || !ok2 {
+			t.Errorf("Match(%q) did not match %q and %q", tt.pattern, tt.better, tt.worse)
+			continue
+		}
+		if better <= worse {
+			t.Errorf("Match(%q): %q scored %d, not above %q with %d", tt.pattern, tt.better, better, tt.worse, worse)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"lanno/internal/file_stat"
+)
+
+// TestWriteListingRecursive prints nested files with their paths and keeps
+// only the requested tag.
+func TestWriteListingRecursive(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "sub"), 0755)
+	os.WriteFile(filepath.Join(dir, "a.txt"), nil, 0644)
+	os.WriteFile(filepath.Join(dir, "sub", "b.txt"), nil, 0644)
+	file_stat.TagCommand([]string{"+keep", "Nested", "file"}, filepath.Join(dir, "sub", "b.txt"), false)
+
+	var out bytes.Buffer
+	opts := file_stat.ListOptions{Recursive: true, Tag: "keep", NoHeader: true}
+	if err := file_stat.WriteListing(&out, dir, opts); err != nil {
+		t.Fatalf("WriteListing() = %v, want nil", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	want := filepath.Join("sub", "b.txt")
+	if len(lines) != 1 || !strings.Contains(lines[0], want) || !strings.Contains(lines[0], "Nested file") {
+		t.Fatalf("WriteListing() printed %q, want one line for %s", lines, want)
+	}
+}
+
+// TestWriteListingNDJSON prints one JSON record per entry.
+func TestWriteListingNDJSON(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), nil, 0644)
+	file_stat.TagCommand([]string{"+work", "Report"}, filepath.Join(dir, "a.txt"), false)
+
+	var out bytes.Buffer
+	if err := file_stat.WriteListing(&out, dir, file_stat.ListOptions{Format: file_stat.FormatNDJSON}); err != nil {
+		t.Fatalf("WriteListing() = %v, want nil", err)
+	}
+
+	var record file_stat.Record
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("output %q is not one JSON record: %v", out.String(), err)
+	}
+	if record.Path != filepath.Join(dir, "a.txt") || record.Description != "Report" || record.IsDir {
+		t.Fatalf("record = %+v, want a.txt described as Report", record)
+	}
+}
+
+// TestWalkEntriesSymlinks descends into symlinked directories only when asked
+// and stops at symlink loops.
+func TestWalkEntriesSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "real"), 0755)
+	os.WriteFile(filepath.Join(dir, "real", "a.txt"), nil, 0644)
+	if err := os.Symlink("real", filepath.Join(dir, "link")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	os.Symlink("..", filepath.Join(dir, "real", "loop"))
+
+	walk := func(opts file_stat.WalkOptions) []string {
+		var paths []string
+		if err := file_stat.WalkEntries(dir, opts, func(entry file_stat.Entry) {
+			if entry.Info.Name == "a.txt" {
+				rel, _ := filepath.Rel(dir, entry.Path)
+				paths = append(paths, rel)
+			}
+		}); err != nil {
+			t.Fatalf("WalkEntries(%+v) = %v, want nil", opts, err)
+		}
+		return paths
+	}
+
+	if got := walk(file_stat.WalkOptions{}); len(got) != 1 {
+		t.Errorf("WalkEntries() found %q, want only real/a.txt", got)
+	}
+	got := walk(file_stat.WalkOptions{FollowSymlinks: true})
+	if want := []string{filepath.Join("link", "a.txt"), filepath.Join("real", "a.txt")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("WalkEntries(FollowSymlinks) found %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,46 @@
+package test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"lanno/internal/file_stat"
+	"lanno/internal/store"
+)
+
+// TestConcurrentTagCommands tags different files of one directory from many
+// goroutines at once and checks that no update is lost.
+func TestConcurrentTagCommands(t *testing.T) {
+	const n = 32
+	dir := t.TempDir()
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("file%02d.txt", i))
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := file_stat.TagCommand([]string{"+generated"}, path, false); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	s := store.NewJSONStore(filepath.Join(dir, ".lanno.json"))
+	if err := s.Load(); err != nil {
+		t.Fatalf("Load() = %v, want nil", err)
+	}
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("file%02d.txt", i)
+		if info, ok := s.Get(name); !ok || len(info.Tags) != 1 {
+			t.Errorf("annotation of %s = %+v, %v; want one tag", name, info, ok)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"lanno/internal/config"
+	"lanno/internal/file_stat"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TestListingColumns prints the optional columns for files, directories and
+// symlinks.
+func TestListingColumns(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "big.bin"), make([]byte, 3000), 0640)
+	os.MkdirAll(filepath.Join(dir, "sub", "inner"), 0755)
+	os.WriteFile(filepath.Join(dir, "sub", "a.txt"), nil, 0644)
+	os.WriteFile(filepath.Join(dir, "sub", ".hidden"), nil, 0644)
+	if err := os.Symlink("big.bin", filepath.Join(dir, "link")); err != nil {
+		t.Skip("symlinks are not supported:", err)
+	}
+	// Fixed modes, whatever the umask
+	os.Chmod(filepath.Join(dir, "big.bin"), 0640)
+	os.Chmod(filepath.Join(dir, "sub"), 0755)
+
+	columns, err := file_stat.ParseColumns("size,mode,target,entries")
+	if err != nil {
+		t.Fatalf("ParseColumns() = %v, want nil", err)
+	}
+	var out bytes.Buffer
+	if err := file_stat.WriteListing(&out, dir, file_stat.ListOptions{Columns: columns}); err != nil {
+		t.Fatalf("WriteListing() = %v, want nil", err)
+	}
+
+	lines := map[string]string{}
+	for _, line := range strings.Split(out.String(), "\n") {
+		if fields := strings.Fields(strings.ReplaceAll(line, "│", " ")); len(fields) > 1 {
+			lines[fields[1]] = strings.Join(fields[2:], " ")
+		}
+	}
+	for name, want := range map[string]string{
+		"Tags":    "Description Size Mode Target Entries",
+		"big.bin": "2.9K -rw-r-----",
+		"link":    "7 lrwxrwxrwx big.bin",
+		"sub":     "drwxr-xr-x 2",
+	} {
+		if lines[name] != want {
+			t.Errorf("line of %s = %q, want %q\n%s", name, lines[name], want, out.String())
+		}
+	}
+}
+
+// TestParseColumnsUnknown rejects columns lanno does not know.
+func TestParseColumnsUnknown(t *testing.T) {
+	if _, err := file_stat.ParseColumns("size,inode"); !errors.Is(err, file_stat.ErrSyntax) {
+		t.Errorf("ParseColumns(size,inode) = %v, want ErrSyntax", err)
+	}
+	columns, err := file_stat.ParseColumns("owner, size,owner")
+	if err != nil || strings.Join(columns, ",") != "owner,size" {
+		t.Errorf("ParseColumns() = %q, %v, want [owner size]", columns, err)
+	}
+}
+
+// TestConfigColumns reads the default columns from the configuration file
+// and shows them in the browser.
+func TestConfigColumns(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("HOME", home)
+	t.Setenv("AppData", home)
+	cfg, err := config.Load()
+	if err != nil || cfg.Columns != nil {
+		t.Fatalf("Load() without a file = %v, %v, want the zero Config", cfg, err)
+	}
+
+	path, _ := config.Path()
+	os.MkdirAll(filepath.Dir(path), 0755)
+	os.WriteFile(path, []byte(`{"columns": ["mode", "entries"]}`), 0644)
+	if cfg, err = config.Load(); err != nil {
+		t.Fatalf("Load() = %v, want nil", err)
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), nil, 0644)
+	os.Chmod(filepath.Join(dir, "a.txt"), 0644)
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(dir)
+
+	var m tea.Model = file_stat.NewModel().WithColumns(cfg.Columns)
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 100, Height: 20})
+	if view := m.View(); !strings.Contains(view, "Mode") || !strings.Contains(view, "Entries") || !strings.Contains(view, "-rw-r--r--") {
+		t.Errorf("browser does not show the configured columns:\n%s", view)
+	}
+}
@@ -0,0 +1,168 @@
+package test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"lanno/internal/file_stat"
+	"lanno/internal/store"
+)
+
+// TestMoveFileAcrossDirectories carries the annotation into the .lanno.json
+// of the target directory.
+func TestMoveFileAcrossDirectories(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "a", "old.go")
+	os.MkdirAll(filepath.Join(root, "a"), 0755)
+	os.MkdirAll(filepath.Join(root, "b"), 0755)
+	os.WriteFile(src, []byte("package a"), 0644)
+	file_stat.TagCommand([]string{"+entry"}, src, false)
+
+	dst := filepath.Join(root, "b", "new.go")
+	if err := file_stat.MoveFile(src, dst); err != nil {
+		t.Fatalf("MoveFile() = %v, want nil", err)
+	}
+
+	if _, err := os.Stat(dst); err != nil {
+		t.Fatalf("target missing after move: %v", err)
+	}
+	from := store.NewJSONStore(filepath.Join(root, "a", ".lanno.json"))
+	from.Load()
+	if _, ok := from.Get("old.go"); ok {
+		t.Errorf("annotation of old.go left behind")
+	}
+	to := store.NewJSONStore(filepath.Join(root, "b", ".lanno.json"))
+	to.Load()
+	if info, ok := to.Get("new.go"); !ok || len(info.Tags) != 1 || info.Tags[0] != "#entry" {
+		t.Errorf("annotation of new.go = %+v, %v; want #entry", info, ok)
+	}
+}
+
+// TestCopyFile duplicates the annotation next to the copy.
+func TestCopyFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.txt")
+	os.WriteFile(src, []byte("content"), 0644)
+	file_stat.TagCommand([]string{"A", "file"}, src, false)
+
+	if err := file_stat.CopyFile(src, filepath.Join(dir, "b.txt")); err != nil {
+		t.Fatalf("CopyFile() = %v, want nil", err)
+	}
+	infos, _ := file_stat.GetInfoFromAnnoFile(dir)
+	if infos["a.txt"].Description != "A file" || infos["b.txt"].Description != "A file" {
+		t.Fatalf("annotations = %+v, want both described", infos)
+	}
+}
+
+// TestCopyFileOntoItself refuses targets that resolve to the source and
+// leaves its content and annotation alone.
+func TestCopyFileOntoItself(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(dir)
+	os.WriteFile("a.txt", []byte("content"), 0644)
+	file_stat.TagCommand([]string{"+keep"}, "a.txt", false)
+
+	for _, dst := range []string{".", "./a.txt"} {
+		if err := file_stat.CopyFile("a.txt", dst); err == nil {
+			t.Errorf("CopyFile(a.txt, %s) = nil, want an error", dst)
+		}
+		if err := file_stat.MoveFile("a.txt", dst); err == nil {
+			t.Errorf("MoveFile(a.txt, %s) = nil, want an error", dst)
+		}
+		if got, _ := os.ReadFile("a.txt"); string(got) != "content" {
+			t.Fatalf("after %s: content = %q, want %q", dst, got, "content")
+		}
+		infos, _ := file_stat.GetInfoFromAnnoFile(".")
+		if tags := infos["a.txt"].Tags; len(tags) != 1 || tags[0] != "#keep" {
+			t.Fatalf("after %s: tags = %q, want #keep", dst, tags)
+		}
+	}
+}
+
+// failingSaveStore is a JSON store whose Save always fails.
+type failingSaveStore struct {
+	*store.JSONStore
+}
+
+func (s failingSaveStore) Save() error {
+	return errors.New("disk full")
+}
+
+// TestMoveFileSaveFails leaves the file and its annotation where they were
+// when the source directory's .lanno.json cannot be saved, instead of
+// keeping the annotation in both directories.
+func TestMoveFileSaveFails(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "a", "old.go")
+	os.MkdirAll(filepath.Join(root, "a"), 0755)
+	os.MkdirAll(filepath.Join(root, "b"), 0755)
+	os.WriteFile(src, []byte("package a"), 0644)
+	file_stat.TagCommand([]string{"+entry"}, src, false)
+
+	openStore := file_stat.OpenStore
+	defer func() { file_stat.OpenStore = openStore }()
+	file_stat.OpenStore = func(dir string) store.Store {
+		s := store.NewJSONStore(filepath.Join(dir, ".lanno.json"))
+		if filepath.Base(dir) == "a" {
+			return failingSaveStore{s}
+		}
+		return s
+	}
+
+	dst := filepath.Join(root, "b", "new.go")
+	if err := file_stat.MoveFile(src, dst); err == nil {
+		t.Fatal("MoveFile() = nil, want the save error")
+	}
+	file_stat.OpenStore = openStore
+
+	if _, err := os.Stat(src); err != nil {
+		t.Errorf("source not moved back: %v", err)
+	}
+	if infos, _ := file_stat.GetInfoFromAnnoFile(filepath.Join(root, "a")); len(infos["old.go"].Tags) != 1 {
+		t.Errorf("annotation of old.go = %+v, want #entry", infos["old.go"])
+	}
+	if infos, _ := file_stat.GetInfoFromAnnoFile(filepath.Join(root, "b")); len(infos) != 0 {
+		t.Errorf("annotations in b = %+v, want none", infos)
+	}
+}
+
+// TestMoveFileAcrossFileSystems copies the file and its times when it cannot
+// be renamed onto another file system.
+func TestMoveFileAcrossFileSystems(t *testing.T) {
+	other, err := os.MkdirTemp("/dev/shm", "lanno")
+	if err != nil {
+		t.Skip("no second file system:", err)
+	}
+	defer os.RemoveAll(other)
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "old.go")
+	os.WriteFile(src, []byte("package a"), 0640)
+	os.Chmod(src, 0640)
+	modified := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	os.Chtimes(src, modified, modified)
+	file_stat.TagCommand([]string{"+entry"}, src, false)
+
+	dst := filepath.Join(other, "new.go")
+	if err := file_stat.MoveFile(src, dst); err != nil {
+		t.Fatalf("MoveFile() = %v, want nil", err)
+	}
+	if _, err := os.Lstat(src); !os.IsNotExist(err) {
+		t.Errorf("source still exists: %v", err)
+	}
+	stat, err := os.Stat(dst)
+	if err != nil {
+		t.Fatalf("target missing after move: %v", err)
+	}
+	if stat.Mode().Perm() != 0640 || !stat.ModTime().Equal(modified) {
+		t.Errorf("target has mode %v and mtime %v, want -rw-r----- and %v", stat.Mode(), stat.ModTime(), modified)
+	}
+	if infos, _ := file_stat.GetInfoFromAnnoFile(other); len(infos["new.go"].Tags) != 1 {
+		t.Errorf("annotation of new.go = %+v, want #entry", infos["new.go"])
+	}
+}
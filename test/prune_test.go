@@ -0,0 +1,30 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"lanno/internal/file_stat"
+)
+
+// TestPrune reports annotations of deleted files and removes them on request.
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	kept := filepath.Join(dir, "kept.txt")
+	os.WriteFile(kept, nil, 0644)
+	file_stat.TagCommand([]string{"+keep"}, kept, false)
+	file_stat.TagCommand([]string{"+gone"}, filepath.Join(dir, "gone.txt"), true)
+
+	report, err := file_stat.Prune(dir, false)
+	if err != nil || report.Removed || len(report.Orphans) != 1 || report.Orphans[0].Name != "gone.txt" {
+		t.Fatalf("Prune(dry) = %+v, %v; want gone.txt listed but kept", report, err)
+	}
+
+	if report, err = file_stat.Prune(dir, true); err != nil || !report.Removed {
+		t.Fatalf("Prune() = %+v, %v; want removal", report, err)
+	}
+	if report, _ = file_stat.Prune(dir, false); len(report.Orphans) != 0 {
+		t.Fatalf("orphans after prune = %+v, want none", report.Orphans)
+	}
+}
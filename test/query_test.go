@@ -0,0 +1,57 @@
+package test
+
+import (
+	"testing"
+
+	"lanno/internal/query"
+)
+
+// TestQueryMatch evaluates queries against a single annotated file.
+func TestQueryMatch(t *testing.T) {
+	item := query.Item{
+		Name:        "main.go",
+		Tags:        []string{"#backend", "#entry"},
+		Description: "Program entry point",
+	}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"", true},
+		{"tag:backend", true},
+		{"tag:#backend", true},
+		{"tag:back", false},
+		{"tag:back*", true},
+		{"tag:backend AND NOT tag:deprecated", true},
+		{"tag:backend AND tag:deprecated", false},
+		{"tag:deprecated OR tag:entry", true},
+		{"NOT (tag:backend OR tag:frontend)", false},
+		{`desc:"entry point"`, true},
+		{`desc:"point entry"`, false},
+		{"name:*.go", true},
+		{"name:*.rs", false},
+		{"program MAIN", true},
+		{"program tag:frontend", false},
+		{"tag:frontend OR tag:backend AND name:main", true},
+	}
+	for _, tt := range tests {
+		expr, err := query.Parse(tt.query)
+		if err != nil {
+			t.Errorf("Parse(%q) = %v, want nil", tt.query, err)
+			continue
+		}
+		if got := expr.Match(item); got != tt.want {
+			t.Errorf("Parse(%q).Match() = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+// TestQuerySyntaxErrors rejects malformed queries.
+func TestQuerySyntaxErrors(t *testing.T) {
+	for _, q := range []string{"(tag:a", "tag:a)", "tag:a AND", "NOT", `desc:"open`, "OR tag:a"} {
+		if _, err := query.Parse(q); err == nil {
+			t.Errorf("Parse(%q) = nil, want an error", q)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"lanno/internal/file_stat"
+	"lanno/internal/store"
+)
+
+// TestReconcileRename finds a file renamed behind lanno's back by its content
+// hash and moves the annotation to the new name.
+func TestReconcileRename(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "pkg"), 0755)
+	old := filepath.Join(root, "handler.go")
+	os.WriteFile(old, []byte("package main // handler"), 0644)
+	os.WriteFile(filepath.Join(root, "other.go"), []byte("package main // other"), 0644)
+	file_stat.TagCommand([]string{"+backend"}, old, false)
+
+	renamed := filepath.Join(root, "pkg", "http_handler.go")
+	os.Rename(old, renamed)
+
+	if renames, _ := file_stat.FindRenames(root, false); len(renames) != 0 {
+		t.Fatalf("FindRenames(flat) = %+v, want none", renames)
+	}
+	renames, err := file_stat.FindRenames(root, true)
+	if err != nil || len(renames) != 1 || renames[0].From != old || renames[0].To != renamed {
+		t.Fatalf("FindRenames() = %+v, %v; want %s -> %s", renames, err, old, renamed)
+	}
+
+	if err := file_stat.ApplyRenames(renames); err != nil {
+		t.Fatalf("ApplyRenames() = %v, want nil", err)
+	}
+	infos, _ := file_stat.GetInfoFromAnnoFile(filepath.Join(root, "pkg"))
+	if info := infos["http_handler.go"]; len(info.Tags) != 1 || info.Hash == "" {
+		t.Fatalf("annotation after reconcile = %+v, want #backend with hash", info)
+	}
+	if infos, _ := file_stat.GetInfoFromAnnoFile(root); len(infos) != 0 {
+		t.Fatalf("annotations left in root = %+v, want none", infos)
+	}
+}
+
+// TestReconcileEmptyFiles does not match a deleted empty file to another
+// empty file, even when its annotation carries the hash of empty content.
+func TestReconcileEmptyFiles(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "pkg"), 0755)
+	initFile := filepath.Join(root, "pkg", "__init__.py")
+	os.WriteFile(initFile, nil, 0644)
+	file_stat.TagCommand([]string{"+package"}, initFile, false)
+	if infos, _ := file_stat.GetInfoFromAnnoFile(filepath.Join(root, "pkg")); infos["__init__.py"].Hash != "" {
+		t.Fatalf("empty file fingerprinted: %+v", infos["__init__.py"])
+	}
+
+	// As recorded before empty files were left without a fingerprint
+	s := store.NewJSONStore(filepath.Join(root, "pkg", ".lanno.json"))
+	s.Load()
+	s.Put(store.FileInfo{Name: "__init__.py", Tags: []string{"#package"},
+		Hash: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"})
+	s.Save()
+
+	os.Remove(initFile)
+	os.WriteFile(filepath.Join(root, "pkg", "empty_placeholder.txt"), nil, 0644)
+	if renames, err := file_stat.FindRenames(root, true); err != nil || len(renames) != 0 {
+		t.Fatalf("FindRenames() = %+v, %v; want none", renames, err)
+	}
+}
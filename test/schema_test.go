@@ -0,0 +1,103 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"lanno/internal/store"
+)
+
+// TestSchemaMigratesVersion0 upgrades an unversioned file on load and
+// writes it back with the current version.
+func TestSchemaMigratesVersion0(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".lanno.json")
+	os.WriteFile(path, []byte(`{"file_info": [{"name": "./main.go", "tags": ["#entry"], "description": ""}]}`), 0644)
+
+	s := store.NewJSONStore(path)
+	if err := s.Load(); err != nil {
+		t.Fatalf("Load() = %v, want nil", err)
+	}
+	if _, ok := s.Get("main.go"); !ok {
+		t.Fatalf(`Get("main.go") found nothing after migration`)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save() = %v, want nil", err)
+	}
+
+	var data store.LannoFileData
+	content, _ := os.ReadFile(path)
+	json.Unmarshal(content, &data)
+	if data.Version != store.SchemaVersion {
+		t.Fatalf("saved version = %d, want %d", data.Version, store.SchemaVersion)
+	}
+}
+
+// TestSchemaPreservesUnknownFields keeps fields this version does not know
+// about when re-marshalling.
+func TestSchemaPreservesUnknownFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".lanno.json")
+	os.WriteFile(path, []byte(`{"version": 1, "owner": "infra", "file_info": [
+		{"name": "a.txt", "tags": [], "description": "", "reviewed": true}]}`), 0644)
+
+	s := store.NewJSONStore(path)
+	s.Load()
+	info, _ := s.Get("a.txt")
+	info.Description = "changed"
+	s.Put(info)
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save() = %v, want nil", err)
+	}
+
+	content, _ := os.ReadFile(path)
+	for _, want := range []string{`"owner": "infra"`, `"reviewed": true`, `"description": "changed"`} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("saved file lacks %s:\n%s", want, content)
+		}
+	}
+}
+
+// TestSchemaRefusesNewerVersion reads a file from a newer lanno but never
+// overwrites it.
+func TestSchemaRefusesNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".lanno.json")
+	newer := []byte(`{"version": 99, "file_info": [{"name": "a.txt", "tags": ["#x"], "description": ""}]}`)
+	os.WriteFile(path, newer, 0644)
+
+	s := store.NewJSONStore(path)
+	if err := s.Load(); err != nil {
+		t.Fatalf("Load() = %v, want nil", err)
+	}
+	if _, ok := s.Get("a.txt"); !ok {
+		t.Fatalf(`Get("a.txt") found nothing`)
+	}
+	if err := s.Save(); !errors.Is(err, store.ErrNewerSchema) {
+		t.Fatalf("Save() = %v, want ErrNewerSchema", err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != string(newer) {
+		t.Fatalf("file was rewritten to %s", content)
+	}
+}
+
+// TestSchemaOddContent loads a null file as an empty one and reports a
+// negative version as corrupt instead of panicking.
+func TestSchemaOddContent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".lanno.json")
+
+	os.WriteFile(path, []byte(`null`), 0644)
+	s := store.NewJSONStore(path)
+	if err := s.Load(); err != nil {
+		t.Fatalf("Load(null) = %v, want nil", err)
+	}
+	if infos := s.List(); len(infos) != 0 {
+		t.Fatalf("List() = %+v, want none", infos)
+	}
+
+	os.WriteFile(path, []byte(`{"version": -1, "file_info": []}`), 0644)
+	if err := store.NewJSONStore(path).Load(); !errors.Is(err, store.ErrCorrupt) {
+		t.Fatalf("Load(version -1) = %v, want ErrCorrupt", err)
+	}
+}
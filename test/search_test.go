@@ -0,0 +1,38 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"lanno/internal/file_stat"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TestSearchFullDescription finds words of a description that do not fit
+// into the column on a narrow terminal.
+func TestSearchFullDescription(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), nil, 0644)
+	os.WriteFile(filepath.Join(dir, "b.txt"), nil, 0644)
+	description := "A long description that only mentions the word kiwi at its very end"
+	file_stat.TagCommand([]string{description}, filepath.Join(dir, "b.txt"), false)
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(dir)
+
+	var m tea.Model = file_stat.NewModel()
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 60, Height: 20})
+	if strings.Contains(m.View(), "kiwi") {
+		t.Fatalf("description is not truncated at this width:\n%s", m.View())
+	}
+	m = sendKeys(m, "/", tea.KeyTab, "kiwi") // Exact search
+
+	view := m.View()
+	if !strings.Contains(view, "b.txt") || strings.Contains(view, "a.txt") {
+		t.Fatalf("search for kiwi shows:\n%s\nwant only b.txt", view)
+	}
+}
@@ -0,0 +1,61 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"lanno/internal/file_stat"
+)
+
+// TestRecordTimes reads the timestamps without a shell, so names with spaces
+// and quotes work, and prints them in RFC 3339.
+func TestRecordTimes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, `it's a "file".txt`)
+	os.WriteFile(path, nil, 0644)
+	modified := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	os.Chtimes(path, modified, modified)
+
+	var out bytes.Buffer
+	if err := file_stat.WriteListing(&out, dir, file_stat.ListOptions{Format: file_stat.FormatNDJSON}); err != nil {
+		t.Fatalf("WriteListing() = %v, want nil", err)
+	}
+	var record file_stat.Record
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("output %q is not a record: %v", out.String(), err)
+	}
+	if got, err := time.Parse(time.RFC3339, record.Modified); err != nil || !got.Equal(modified) {
+		t.Errorf("Modified = %q, want %s", record.Modified, modified.Format(time.RFC3339))
+	}
+	if got, err := time.Parse(time.RFC3339, record.Accessed); err != nil || !got.Equal(modified) {
+		t.Errorf("Accessed = %q, want %s", record.Accessed, modified.Format(time.RFC3339))
+	}
+	if record.Created != "" {
+		if _, err := time.Parse(time.RFC3339, record.Created); err != nil {
+			t.Errorf("Created = %q, want RFC 3339 or empty", record.Created)
+		}
+	}
+}
+
+// BenchmarkListingTimes lists a directory of 10,000 files with their
+// timestamps.
+func BenchmarkListingTimes(b *testing.B) {
+	dir := b.TempDir()
+	for i := 0; i < 10000; i++ {
+		os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%05d.txt", i)), nil, 0644)
+	}
+	opts := file_stat.ListOptions{Format: file_stat.FormatNDJSON}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := file_stat.WriteListing(io.Discard, dir, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"lanno/internal/file_stat"
+	"lanno/internal/store"
+)
+
+// TestEditAnnotationTags adds and removes tags on an in-memory store.
+func TestEditAnnotationTags(t *testing.T) {
+	s := store.NewMemoryStore(store.FileInfo{Name: "main.go", Tags: []string{"#old"}})
+
+	if err := file_stat.EditAnnotation(s, "main.go", []string{"+entry", "-old", "+entry"}); err != nil {
+		t.Fatalf("EditAnnotation() = %v, want nil", err)
+	}
+	info, ok := s.Get("main.go")
+	if !ok {
+		t.Fatalf(`Get("main.go") found nothing`)
+	}
+	if want := []string{"#entry"}; !reflect.DeepEqual(info.Tags, want) {
+		t.Fatalf("Tags = %q, want %q", info.Tags, want)
+	}
+}
+
+// TestEditAnnotationDescription sets and clears a description.
+func TestEditAnnotationDescription(t *testing.T) {
+	s := store.NewMemoryStore()
+
+	file_stat.EditAnnotation(s, "main.go", []string{"Program", "entry", "point"})
+	if info, _ := s.Get("main.go"); info.Description != "Program entry point" {
+		t.Fatalf("Description = %q, want %q", info.Description, "Program entry point")
+	}
+
+	file_stat.EditAnnotation(s, "main.go", []string{})
+	if info, _ := s.Get("main.go"); info.Description != "" {
+		t.Fatalf("Description = %q, want empty", info.Description)
+	}
+}
+
+// TestEditAnnotationTagsAndDescription sets tags followed by a description
+// and rejects a tag command without a tag name.
+func TestEditAnnotationTagsAndDescription(t *testing.T) {
+	s := store.NewMemoryStore()
+
+	if err := file_stat.EditAnnotation(s, "doc.txt", []string{"+urgent", "Important", "work"}); err != nil {
+		t.Fatalf("EditAnnotation() = %v, want nil", err)
+	}
+	info, _ := s.Get("doc.txt")
+	if info.Description != "Important work" || !reflect.DeepEqual(info.Tags, []string{"#urgent"}) {
+		t.Fatalf("annotation = %+v, want #urgent and description", info)
+	}
+
+	if err := file_stat.EditAnnotation(s, "doc.txt", []string{"+"}); !errors.Is(err, file_stat.ErrSyntax) {
+		t.Errorf("EditAnnotation(+) = %v, want ErrSyntax", err)
+	}
+}
+
+// TestEditAnnotationDescriptionWithDashes keeps words starting with + or -
+// after the first description word as description text.
+func TestEditAnnotationDescriptionWithDashes(t *testing.T) {
+	s := store.NewMemoryStore()
+
+	for _, tt := range []struct {
+		command []string
+		want    string
+	}{
+		{[]string{"Returns", "-1", "on", "error"}, "Returns -1 on error"},
+		{[]string{"+cli", "Fix", "the", "--verbose", "flag", "+1"}, "Fix the --verbose flag +1"},
+	} {
+		if err := file_stat.EditAnnotation(s, "f.go", tt.command); err != nil {
+			t.Fatalf("EditAnnotation(%q) = %v, want nil", tt.command, err)
+		}
+		if info, _ := s.Get("f.go"); info.Description != tt.want {
+			t.Errorf("EditAnnotation(%q) set %q, want %q", tt.command, info.Description, tt.want)
+		}
+	}
+	if info, _ := s.Get("f.go"); !reflect.DeepEqual(info.Tags, []string{"#cli"}) {
+		t.Errorf("Tags = %q, want [#cli]", info.Tags)
+	}
+}
+
+// TestJSONStoreRoundTrip saves annotations and loads them into a new store.
+func TestJSONStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".lanno.json")
+
+	s := store.NewJSONStore(path)
+	if err := s.Load(); err != nil {
+		t.Fatalf("Load() of missing file = %v, want nil", err)
+	}
+	s.Put(store.FileInfo{Name: "a.txt", Tags: []string{"#a"}})
+	s.Put(store.FileInfo{Name: "b.txt", Description: "second"})
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save() = %v, want nil", err)
+	}
+
+	loaded := store.NewJSONStore(path)
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("Load() = %v, want nil", err)
+	}
+	if got, want := loaded.List(), s.List(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("List() = %+v, want %+v", got, want)
+	}
+}
+
+// TestJSONStoreCorrupt refuses to load a malformed file and leaves it intact.
+func TestJSONStoreCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".lanno.json")
+	corrupt := []byte(`{"file_info": [`)
+	if err := os.WriteFile(path, corrupt, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.NewJSONStore(path).Load(); !errors.Is(err, store.ErrCorrupt) {
+		t.Fatalf("Load() = %v, want ErrCorrupt", err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "main.go"), nil, 0644)
+	err := file_stat.TagCommand([]string{"+entry"}, filepath.Join(dir, "main.go"), false)
+	if !errors.Is(err, store.ErrCorrupt) {
+		t.Fatalf("TagCommand() = %v, want ErrCorrupt", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != string(corrupt) {
+		t.Fatalf("corrupt file was rewritten to %q", got)
+	}
+}
+
+// TestTagCommandSameNameInSubdirectories keeps the annotations of files with
+// the same name apart, each in the store of its own directory.
+func TestTagCommandSameNameInSubdirectories(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"src", "test"} {
+		os.Mkdir(filepath.Join(dir, sub), 0755)
+		os.WriteFile(filepath.Join(dir, sub, "main.go"), nil, 0644)
+	}
+
+	if err := file_stat.TagCommand([]string{"+entry"}, filepath.Join(dir, "src", "main.go"), false); err != nil {
+		t.Fatalf("TagCommand(src/main.go) = %v, want nil", err)
+	}
+	if err := file_stat.TagCommand([]string{"+test"}, filepath.Join(dir, "test", "main.go"), false); err != nil {
+		t.Fatalf("TagCommand(test/main.go) = %v, want nil", err)
+	}
+
+	for sub, want := range map[string][]string{"src": {"#entry"}, "test": {"#test"}} {
+		infos, err := file_stat.GetInfoFromAnnoFile(filepath.Join(dir, sub))
+		if err != nil {
+			t.Fatalf("GetInfoFromAnnoFile(%s) = %v, want nil", sub, err)
+		}
+		if got := infos["main.go"].Tags; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s/main.go tags = %q, want %q", sub, got, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".lanno.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Stat(.lanno.json) = %v, want no store in the parent directory", err)
+	}
+}
+
+// TestJSONStoreBackup keeps the previous version next to the store.
+func TestJSONStoreBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".lanno.json")
+
+	s := store.NewJSONStore(path).WithBackup(true)
+	s.Put(store.FileInfo{Name: "a.txt", Description: "first"})
+	s.Save()
+	first, _ := os.ReadFile(path)
+
+	s.Put(store.FileInfo{Name: "a.txt", Description: "second"})
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save() = %v, want nil", err)
+	}
+	if backup, _ := os.ReadFile(path + ".bak"); string(backup) != string(first) {
+		t.Fatalf("backup = %q, want %q", backup, first)
+	}
+}
+
+// TestTagCommandMissingFile rejects files that do not exist unless forced.
+func TestTagCommandMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "typo.go")
+
+	if err := file_stat.TagCommand([]string{"+important"}, path, false); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("TagCommand() = %v, want ErrNotExist", err)
+	}
+	if err := file_stat.TagCommand([]string{"+important"}, path, true); err != nil {
+		t.Fatalf("TagCommand(force) = %v, want nil", err)
+	}
+}
@@ -0,0 +1,175 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"lanno/internal/table"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TestTableSortNested sorts rows among their siblings and keeps nested rows
+// below the row they belong to.
+func TestTableSortNested(t *testing.T) {
+	row := func(id, parent string, depth int, size int64) table.Row {
+		return table.NewRow(table.RowData{"name": id, "size": size}).
+			WithID(id).
+			WithParent(parent, depth)
+	}
+	rows := []table.Row{
+		row("b", "", 0, 2),
+		row("dir", "", 0, 0),
+		row("dir/y", "dir", 1, 5),
+		row("dir/x", "dir", 1, 9),
+		row("a", "", 0, 7),
+	}
+	ids := func(tbl *table.Table) string {
+		var ids []string
+		for _, r := range tbl.Rows {
+			ids = append(ids, r.ID)
+		}
+		return strings.Join(ids, " ")
+	}
+
+	tbl := table.New([]table.Column{table.NewColumn("name", "Name", 10)}).
+		WithGroup("directories first", func(r table.Row) bool { return r.ID == "dir" }).
+		WithRows(rows)
+	if got, want := ids(tbl), "b dir dir/y dir/x a"; got != want {
+		t.Errorf("unsorted rows = %q, want %q", got, want)
+	}
+
+	tbl.WithSort(table.Sort{Key: "name"})
+	if got, want := ids(tbl), "a b dir dir/x dir/y"; got != want {
+		t.Errorf("rows by name = %q, want %q", got, want)
+	}
+
+	tbl.WithSort(table.Sort{Key: "size", Descending: true})
+	if got, want := ids(tbl), "a b dir dir/x dir/y"; got != want {
+		t.Errorf("rows by size, descending = %q, want %q", got, want)
+	}
+
+	tbl.WithSort(table.Sort{Key: "size", GroupFirst: true})
+	if got, want := ids(tbl), "dir dir/y dir/x b a"; got != want {
+		t.Errorf("rows by size, group first = %q, want %q", got, want)
+	}
+	if !strings.Contains(tbl.View(), "Name") || strings.Contains(tbl.View(), "▲") {
+		t.Errorf("header of a table sorted by a hidden key:\n%s", tbl.View())
+	}
+}
+
+// TestTableLayout lays out fitted, flexible and fixed columns for a width
+// and keeps the columns the user moved, resized or hid.
+func TestTableLayout(t *testing.T) {
+	columns := []table.Column{
+		table.NewColumn("name", "Name", 0).WithFit(true).WithMinWidth(6),
+		table.NewColumn("desc", "Desc", 0).WithFit(true).WithMinWidth(8).WithFlex(1),
+		table.NewColumn("size", "Size", 5).WithMinWidth(5),
+	}
+	rows := []table.Row{
+		table.NewRow(table.RowData{"name": "readme.md", "desc": "read me", "size": "1.0K"}).WithID("readme.md"),
+		table.NewRow(table.RowData{"name": "go.mod", "desc": "module", "size": "40"}).WithID("go.mod"),
+	}
+	header := func(tbl *table.Table) string {
+		return strings.SplitN(tbl.View(), "\n", 2)[0]
+	}
+	newTable := func(width int) *table.Table {
+		tbl := table.New(columns).WithWidth(width).WithFocused(true).WithRows(rows)
+		tbl.SetStyles(table.PlainStyles())
+		return tbl
+	}
+	press := func(tbl *table.Table, keys string) {
+		for _, r := range keys {
+			tbl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+
+	for _, tt := range []struct {
+		width int
+		want  string
+	}{
+		{0, "Name     │Desc   │Size "},                   // Natural widths
+		{40, "Name     │Desc                    │Size "}, // Spare width goes to Desc
+		{22, "Name   │Desc    │Size "},                   // Desc, then Name shrink
+		{10, "Name  │Desc    │Size "},                    // Never below MinWidth
+	} {
+		if got := header(newTable(tt.width)); got != tt.want {
+			t.Errorf("header at width %d = %q, want %q", tt.width, got, tt.want)
+		}
+	}
+
+	tbl := newTable(40)
+	press(tbl, "]]}") // Focus Name, then Desc, and move it right
+	if got, want := header(tbl), "Name     │Size │Desc                    "; got != want {
+		t.Errorf("header after moving Desc = %q, want %q", got, want)
+	}
+	press(tbl, "[[<<]x") // Narrow Name by 4, then hide Size
+	if got, want := header(tbl), "Name │Desc                              "; got != want {
+		t.Errorf("header after resizing and hiding = %q, want %q", got, want)
+	}
+
+	rebuilt := newTable(40).WithLayout(tbl.LayoutState())
+	if got, want := header(rebuilt), header(tbl); got != want {
+		t.Errorf("header of the rebuilt table = %q, want %q", got, want)
+	}
+	press(rebuilt, "X")
+	if got, want := header(rebuilt), "Name     │Desc                    │Size "; got != want {
+		t.Errorf("header after X = %q, want %q", got, want)
+	}
+
+	// New rows are measured again
+	rebuilt.WithRows(append(rows, table.NewRow(table.RowData{"name": "a_longer_name.go"}).WithID("a_longer_name.go")))
+	if got, want := header(rebuilt), "Name            │Desc             │Size "; got != want {
+		t.Errorf("header with a longer name = %q, want %q", got, want)
+	}
+}
+
+// TestTableKeysPickColumnFirst only picks the first column when a column key
+// is pressed with no column picked.
+func TestTableKeysPickColumnFirst(t *testing.T) {
+	tbl := table.New([]table.Column{
+		table.NewColumn("name", "Name", 6),
+		table.NewColumn("size", "Size", 5),
+	}).WithFocused(true)
+	tbl.SetStyles(table.PlainStyles())
+	header := strings.SplitN(tbl.View(), "\n", 2)[0]
+
+	for _, key := range []string{"x", "<", ">", "{", "}"} {
+		tbl.WithLayout(table.Layout{})
+		tbl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		if got := strings.SplitN(tbl.View(), "\n", 2)[0]; got != header {
+			t.Errorf("header after %s = %q, want %q", key, got, header)
+		}
+		if focus := tbl.LayoutState().Focus; focus != "name" {
+			t.Errorf("focus after %s = %q, want name", key, focus)
+		}
+	}
+
+	tbl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if got, want := strings.SplitN(tbl.View(), "\n", 2)[0], "Size "; got != want {
+		t.Errorf("header after a second x = %q, want %q", got, want)
+	}
+}
+
+// TestTableEscKeepsMarks stops picking columns with esc before esc clears
+// the marks.
+func TestTableEscKeepsMarks(t *testing.T) {
+	tbl := table.New([]table.Column{table.NewColumn("name", "Name", 10)}).
+		WithRows([]table.Row{
+			table.NewRow(table.RowData{"name": "a"}).WithID("a"),
+			table.NewRow(table.RowData{"name": "b"}).WithID("b"),
+		})
+	press := func(key tea.KeyMsg) { tbl.Update(key) }
+	esc := tea.KeyMsg{Type: tea.KeyEsc}
+
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{' '}}) // Mark a
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{']'}}) // Pick a column
+	press(esc)
+	if got := tbl.MarkedIDs(); len(got) != 1 || got[0] != "a" {
+		t.Fatalf("marks after esc with a picked column = %q, want [a]", got)
+	}
+	press(esc)
+	if got := tbl.MarkedIDs(); len(got) != 0 {
+		t.Fatalf("marks after a second esc = %q, want none", got)
+	}
+}